@@ -0,0 +1,277 @@
+// Package actor runs actors: goroutines that own their state and are only reached through messages,
+// the channel-ownership style of the goroutine demos made into a runtime.
+// Each actor has a typed mailbox, handles one message at a time, and can be supervised,
+// i.e. restarted from a fresh state when it fails.
+package actor
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Actor handles the messages of type M sent to it. A returned error (or a panic) means the actor failed:
+// it stops, or is restarted if it has a supervisor.
+type Actor[M any] interface {
+	Receive(self *Ref[M], msg M) error
+}
+
+// Func is a stateless Actor defined by a function.
+type Func[M any] func(self *Ref[M], msg M) error
+
+func (f Func[M]) Receive(self *Ref[M], msg M) error {
+	return f(self, msg)
+}
+
+// Actors can implement the lifecycle hooks below to be told about their start, restart and stop.
+
+// PreStarter is implemented by actors that need to set up before their first message, after each restart too.
+// A returned error counts as a failure.
+type PreStarter interface {
+	PreStart() error
+}
+
+// PreRestarter is implemented by actors that need to clean up before being replaced by a fresh instance.
+type PreRestarter interface {
+	PreRestart(reason error)
+}
+
+// PostStopper is implemented by actors that need to clean up once stopped for good.
+type PostStopper interface {
+	PostStop()
+}
+
+var (
+	// ErrStopped is returned when sending to an actor that is stopped.
+	ErrStopped = errors.New("actor: stopped")
+	// ErrTimeout is returned by Ask when no reply came in time.
+	ErrTimeout = errors.New("actor: ask timed out")
+)
+
+// PanicError is the failure of an actor that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("actor: panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Options tune how an actor is spawned.
+type Options struct {
+	// Name identifies the actor in errors, "actor" if empty.
+	Name string
+	// Mailbox is the number of messages that can wait for the actor before Send blocks.
+	Mailbox int
+	// Supervisor restarts the actor when it fails, nil means the actor just stops.
+	Supervisor *Supervisor
+}
+
+// Ref is how an actor is reached. It is safe to use concurrently.
+type Ref[M any] struct {
+	name     string
+	newActor func() Actor[M]
+	sup      *Supervisor
+	mailbox  chan M
+	restart  chan error    // restart requests of the supervisor
+	stop     chan struct{} // closed by Stop
+	stopOnce sync.Once
+	done     chan struct{} // closed once stopped
+	err      error         // why the actor stopped, nil if by Stop, read after done
+}
+
+// Spawn starts an actor created by newActor. newActor is called again for each restart,
+// so the new instance starts from a clean state; the messages waiting in the mailbox are kept.
+func Spawn[M any](newActor func() Actor[M], opts Options) *Ref[M] {
+	if opts.Name == "" {
+		opts.Name = "actor"
+	}
+	r := &Ref[M]{
+		name:     opts.Name,
+		newActor: newActor,
+		sup:      opts.Supervisor,
+		mailbox:  make(chan M, max(opts.Mailbox, 0)),
+		restart:  make(chan error, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if r.sup != nil && !r.sup.add(r) {
+		// The supervisor has given up or is stopped, the actor never starts.
+		r.err = ErrStopped
+		close(r.done)
+		return r
+	}
+	go r.run()
+	return r
+}
+
+// Name returns the name of the actor.
+func (r *Ref[M]) Name() string {
+	return r.name
+}
+
+// Send puts msg in the mailbox of the actor, waiting for room if it is full.
+// It returns ErrStopped if the actor is stopped.
+func (r *Ref[M]) Send(msg M) error {
+	select {
+	case <-r.done:
+		return ErrStopped
+	default:
+	}
+	select {
+	case r.mailbox <- msg:
+		return nil
+	case <-r.done:
+		return ErrStopped
+	}
+}
+
+// Reply is where an actor sends the answer to a message sent with Ask.
+type Reply[R any] struct {
+	ch chan R
+}
+
+// Send sends the answer. Only the first answer is received, the others are dropped.
+func (r Reply[R]) Send(v R) {
+	select {
+	case r.ch <- v:
+	default:
+	}
+}
+
+// Ask sends the message built by msg around a Reply, and waits for the actor to answer through it.
+// It returns ErrTimeout if the answer doesn't come within timeout, e.g. because the actor failed on the message.
+func Ask[M, R any](r *Ref[M], timeout time.Duration, msg func(Reply[R]) M) (R, error) {
+	var zero R
+	reply := Reply[R]{make(chan R, 1)}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case r.mailbox <- msg(reply):
+	case <-r.done:
+		return zero, ErrStopped
+	case <-t.C:
+		return zero, ErrTimeout
+	}
+	select {
+	case v := <-reply.ch:
+		return v, nil
+	case <-r.done:
+		select {
+		case v := <-reply.ch:
+			return v, nil
+		default:
+			return zero, ErrStopped
+		}
+	case <-t.C:
+		return zero, ErrTimeout
+	}
+}
+
+// Stop asks the actor to stop once done with its current message, the messages left in the mailbox are dropped.
+// It does not wait, see Done.
+func (r *Ref[M]) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+// Done returns a channel closed once the actor is stopped.
+func (r *Ref[M]) Done() <-chan struct{} {
+	return r.done
+}
+
+// Err returns why the actor stopped: nil if by Stop, or its last failure if it was not restarted.
+// It must be called once Done is closed.
+func (r *Ref[M]) Err() error {
+	return r.err
+}
+
+// restartRequest is the reason of a restart asked by the supervisor because of another actor's failure.
+type restartRequest struct {
+	reason error
+}
+
+func (r restartRequest) Error() string {
+	return "actor: restarted along with a failed sibling: " + r.reason.Error()
+}
+
+func (r *Ref[M]) run() {
+	defer close(r.done)
+	a := r.newActor()
+	for {
+		reason := r.loop(a)
+		if reason == nil {
+			break
+		}
+		// A restart requested by the supervisor doesn't count as a failure of this actor.
+		if _, ok := reason.(restartRequest); !ok && (r.sup == nil || !r.sup.failed(r, reason)) {
+			r.err = reason
+			break
+		}
+		if h, ok := a.(PreRestarter); ok {
+			h.PreRestart(reason)
+		}
+		a = r.newActor()
+	}
+	if r.sup != nil {
+		r.sup.remove(r)
+	}
+	if h, ok := a.(PostStopper); ok {
+		h.PostStop()
+	}
+}
+
+// loop starts a and hands it the messages until it is stopped (nil), fails or has to restart.
+func (r *Ref[M]) loop(a Actor[M]) error {
+	if h, ok := a.(PreStarter); ok {
+		if err := protect(h.PreStart); err != nil {
+			return err
+		}
+	}
+	for {
+		// Check stop first: a busy mailbox must not delay it.
+		select {
+		case <-r.stop:
+			return nil
+		default:
+		}
+		select {
+		case <-r.stop:
+			return nil
+		case reason := <-r.restart:
+			return restartRequest{reason}
+		case msg := <-r.mailbox:
+			if err := protect(func() error { return a.Receive(r, msg) }); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// protect calls fn, turning a panic into a *PanicError.
+func protect(fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// requestRestart and stopAsync let the supervisor act on actors of any message type.
+func (r *Ref[M]) requestRestart(reason error) {
+	select {
+	case r.restart <- reason:
+	default: // a restart is already pending
+	}
+}
+
+func (r *Ref[M]) stopAsync() {
+	r.Stop()
+}
+
+func (r *Ref[M]) wait() {
+	<-r.done
+}
@@ -0,0 +1,155 @@
+package actor
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-demo/leaktest"
+)
+
+// msg fails the actor receiving it if fail is set, and otherwise answers with the generation
+// of the instance, i.e. how many instances of the actor were started before it.
+type msg struct {
+	fail  error
+	reply Reply[int]
+}
+
+type testActor struct {
+	gen     int
+	stopped func()
+}
+
+func (a *testActor) Receive(self *Ref[msg], m msg) error {
+	if m.fail != nil {
+		return m.fail
+	}
+	m.reply.Send(a.gen)
+	return nil
+}
+
+func (a *testActor) PostStop() {
+	if a.stopped != nil {
+		a.stopped()
+	}
+}
+
+// starts counts the instances started per actor name.
+type starts struct {
+	mux sync.Mutex
+	n   map[string]int
+}
+
+func (s *starts) spawn(name string, sup *Supervisor) *Ref[msg] {
+	return Spawn(func() Actor[msg] {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+		if s.n == nil {
+			s.n = make(map[string]int)
+		}
+		s.n[name]++
+		return &testActor{gen: s.n[name]}
+	}, Options{Name: name, Mailbox: 10, Supervisor: sup})
+}
+
+func (s *starts) get(name string) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.n[name]
+}
+
+func generation(r *Ref[msg]) (int, error) {
+	return Ask(r, time.Second, func(reply Reply[int]) msg { return msg{reply: reply} })
+}
+
+func TestAsk(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	var s starts
+	r := s.spawn("a", nil)
+	if gen, err := generation(r); gen != 1 || err != nil {
+		t.Errorf("Ask: got %d, %v, want 1", gen, err)
+	}
+
+	// An actor that never answers.
+	mute := Spawn(func() Actor[msg] {
+		return Func[msg](func(*Ref[msg], msg) error { return nil })
+	}, Options{})
+	start := time.Now()
+	_, err := Ask(mute, 10*time.Millisecond, func(reply Reply[int]) msg { return msg{reply: reply} })
+	if err != ErrTimeout {
+		t.Errorf("Ask of a mute actor: got %v, want ErrTimeout", err)
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Errorf("Ask timed out after %v", d)
+	}
+
+	// An actor too busy to take the message: its mailbox is full.
+	block := make(chan struct{})
+	busy := Spawn(func() Actor[msg] {
+		return Func[msg](func(*Ref[msg], msg) error {
+			<-block
+			return nil
+		})
+	}, Options{Mailbox: 1})
+	busy.Send(msg{})
+	busy.Send(msg{})
+	if _, err := Ask(busy, 10*time.Millisecond, func(reply Reply[int]) msg { return msg{reply: reply} }); err != ErrTimeout {
+		t.Errorf("Ask of a busy actor: got %v, want ErrTimeout", err)
+	}
+	close(block)
+
+	// An actor failing on the message stops without answering.
+	boom := errors.New("boom")
+	failing := Spawn(func() Actor[msg] {
+		return Func[msg](func(*Ref[msg], msg) error { return boom })
+	}, Options{})
+	if _, err := Ask(failing, time.Second, func(reply Reply[int]) msg { return msg{reply: reply} }); err != ErrStopped {
+		t.Errorf("Ask of a failing actor: got %v, want ErrStopped", err)
+	}
+	if err := failing.Err(); err != boom {
+		t.Errorf("Err: got %v, want boom", err)
+	}
+
+	for _, r := range []*Ref[msg]{r, mute, busy} {
+		r.Stop()
+		<-r.Done()
+		if err := r.Err(); err != nil {
+			t.Errorf("%s stopped with %v", r.Name(), err)
+		}
+	}
+	if err := r.Send(msg{}); err != ErrStopped {
+		t.Errorf("Send after Stop: got %v, want ErrStopped", err)
+	}
+	if _, err := generation(r); err != ErrStopped {
+		t.Errorf("Ask after Stop: got %v, want ErrStopped", err)
+	}
+}
+
+func TestFailure(t *testing.T) {
+	stopped := make(chan struct{})
+	r := Spawn(func() Actor[msg] {
+		return &testActor{stopped: func() { close(stopped) }}
+	}, Options{})
+	boom := errors.New("boom")
+	r.Send(msg{fail: boom})
+	<-r.Done()
+	<-stopped // PostStop is called after a failure too
+	if err := r.Err(); err != boom {
+		t.Errorf("Err: got %v, want boom", err)
+	}
+
+	r = Spawn(func() Actor[msg] {
+		return Func[msg](func(*Ref[msg], msg) error {
+			var m map[string]int
+			m["a"] = 1
+			return nil
+		})
+	}, Options{})
+	r.Send(msg{})
+	<-r.Done()
+	var pe *PanicError
+	if !errors.As(r.Err(), &pe) {
+		t.Errorf("Err: got %v, want a PanicError", r.Err())
+	}
+}
@@ -0,0 +1,147 @@
+package actor
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Strategy tells which actors a supervisor restarts when one of them fails.
+type Strategy int
+
+const (
+	// OneForOne restarts only the failed actor.
+	OneForOne Strategy = iota
+	// OneForAll restarts all the actors of the supervisor, for actors that only work together.
+	OneForAll
+	// RestForOne restarts the failed actor and the actors spawned after it, which may depend on it.
+	RestForOne
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one-for-one"
+	case OneForAll:
+		return "one-for-all"
+	case RestForOne:
+		return "rest-for-one"
+	}
+	return fmt.Sprintf("Strategy(%d)", int(s))
+}
+
+// child is an actor of any message type, as seen by its supervisor.
+type child interface {
+	Name() string
+	requestRestart(reason error)
+	stopAsync()
+	wait()
+}
+
+// Supervisor restarts the actors spawned with it when they fail. When they fail too often,
+// more than maxRestarts times within window, the supervisor gives up: it stops all its actors.
+type Supervisor struct {
+	strategy    Strategy
+	maxRestarts int
+	window      time.Duration
+
+	mux      sync.Mutex
+	children []child     // in spawn order
+	restarts []time.Time // within the window
+	stopped  bool
+	err      error
+}
+
+// NewSupervisor returns a supervisor restarting its actors with the given strategy.
+func NewSupervisor(strategy Strategy, maxRestarts int, window time.Duration) *Supervisor {
+	if maxRestarts < 0 {
+		panic("actor: negative maxRestarts")
+	}
+	return &Supervisor{strategy: strategy, maxRestarts: maxRestarts, window: window}
+}
+
+func (s *Supervisor) add(c child) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.stopped {
+		return false
+	}
+	s.children = append(s.children, c)
+	return true
+}
+
+func (s *Supervisor) remove(c child) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if i := slices.Index(s.children, c); i >= 0 {
+		s.children = slices.Delete(s.children, i, i+1)
+	}
+}
+
+// failed is called by an actor that failed, it reports whether the actor is to restart.
+func (s *Supervisor) failed(c child, reason error) bool {
+	now := time.Now()
+	s.mux.Lock()
+	if s.stopped {
+		s.mux.Unlock()
+		return false
+	}
+	i := 0
+	for i < len(s.restarts) && now.Sub(s.restarts[i]) > s.window {
+		i++
+	}
+	s.restarts = s.restarts[i:]
+	if len(s.restarts) >= s.maxRestarts {
+		s.err = fmt.Errorf("actor: supervisor gave up after %d restarts in %v, %s failed: %w", len(s.restarts), s.window, c.Name(), reason)
+		s.stopped = true
+		others := slices.Clone(s.children)
+		s.mux.Unlock()
+		for _, o := range others {
+			if o != c {
+				o.stopAsync()
+			}
+		}
+		return false
+	}
+	s.restarts = append(s.restarts, now)
+
+	var others []child
+	switch s.strategy {
+	case OneForAll:
+		others = s.children
+	case RestForOne:
+		if i := slices.Index(s.children, c); i >= 0 {
+			others = s.children[i+1:]
+		}
+	}
+	others = slices.Clone(others)
+	s.mux.Unlock()
+	for _, o := range others {
+		if o != c {
+			o.requestRestart(reason)
+		}
+	}
+	return true
+}
+
+// Err returns why the supervisor gave up, nil if it didn't.
+func (s *Supervisor) Err() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.err
+}
+
+// Stop stops all the actors of the supervisor and waits for them. Actors spawned afterwards don't start.
+func (s *Supervisor) Stop() {
+	s.mux.Lock()
+	s.stopped = true
+	children := slices.Clone(s.children)
+	s.mux.Unlock()
+	for _, c := range children {
+		c.stopAsync()
+	}
+	for _, c := range children {
+		c.wait()
+	}
+}
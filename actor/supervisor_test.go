@@ -0,0 +1,118 @@
+package actor
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-demo/leaktest"
+)
+
+// settle waits until the starts per actor are want, then a little more to see that no other restart comes.
+func settle(t *testing.T, s *starts, want map[string]int) {
+	t.Helper()
+	got := func() map[string]int {
+		m := make(map[string]int, len(want))
+		for name := range want {
+			m[name] = s.get(name)
+		}
+		return m
+	}
+	deadline := time.Now().Add(time.Second)
+	for !maps.Equal(got(), want) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if g := got(); !maps.Equal(g, want) {
+		t.Errorf("starts: got %v, want %v", g, want)
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		strategy Strategy
+		want     map[string]int // starts once "b" failed
+	}{
+		{OneForOne, map[string]int{"a": 1, "b": 2, "c": 1}},
+		{OneForAll, map[string]int{"a": 2, "b": 2, "c": 2}},
+		{RestForOne, map[string]int{"a": 1, "b": 2, "c": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			defer leaktest.Check(t, leaktest.Options{})()
+			sup := NewSupervisor(tt.strategy, 3, time.Minute)
+			var s starts
+			refs := map[string]*Ref[msg]{}
+			for _, name := range []string{"a", "b", "c"} {
+				refs[name] = s.spawn(name, sup)
+			}
+			settle(t, &s, map[string]int{"a": 1, "b": 1, "c": 1})
+			refs["b"].Send(msg{fail: errors.New("boom")})
+			settle(t, &s, tt.want)
+			// The restarted actors are new instances, and they all still answer.
+			for name, r := range refs {
+				if gen, err := generation(r); err != nil || gen != tt.want[name] {
+					t.Errorf("%s: got generation %d, %v, want %d", name, gen, err, tt.want[name])
+				}
+			}
+			if err := sup.Err(); err != nil {
+				t.Errorf("Err: %v", err)
+			}
+			sup.Stop()
+			for name, r := range refs {
+				if err := r.Err(); err != nil {
+					t.Errorf("%s stopped with %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSupervisorGivesUp(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	sup := NewSupervisor(OneForOne, 2, time.Minute)
+	var s starts
+	a, b := s.spawn("a", sup), s.spawn("b", sup)
+	boom := errors.New("boom")
+	for i := 1; i <= 2; i++ {
+		a.Send(msg{fail: boom})
+		settle(t, &s, map[string]int{"a": 1 + i, "b": 1})
+	}
+	// The third failure within the window is one too many: every actor stops.
+	a.Send(msg{fail: boom})
+	<-a.Done()
+	<-b.Done()
+	if err := a.Err(); err != boom {
+		t.Errorf("failed actor: got %v, want boom", err)
+	}
+	if err := b.Err(); err != nil {
+		t.Errorf("sibling: got %v, want nil", err)
+	}
+	err := sup.Err()
+	if !errors.Is(err, boom) || !strings.Contains(err.Error(), "gave up after 2 restarts") {
+		t.Errorf("supervisor: got %v", err)
+	}
+	// Actors spawned now never start.
+	if c := s.spawn("c", sup); c.Err() != ErrStopped || s.get("c") != 0 {
+		t.Errorf("actor spawned after giving up: got %v and %d starts", c.Err(), s.get("c"))
+	}
+}
+
+func TestSupervisorWindow(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	sup := NewSupervisor(OneForOne, 1, 20*time.Millisecond)
+	var s starts
+	a := s.spawn("a", sup)
+	a.Send(msg{fail: errors.New("first")})
+	settle(t, &s, map[string]int{"a": 2})
+	// The first restart is out of the window by now: this failure is only the first within it.
+	time.Sleep(30 * time.Millisecond)
+	a.Send(msg{fail: errors.New("second")})
+	settle(t, &s, map[string]int{"a": 3})
+	if err := sup.Err(); err != nil {
+		t.Errorf("Err: %v", err)
+	}
+	sup.Stop()
+}
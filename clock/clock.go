@@ -0,0 +1,96 @@
+// Package clock abstracts the current time and timers so that time-based code can be driven
+// by something other than the wall clock (e.g. a fixed or manually advanced time in tests).
+package clock
+
+import "time"
+
+// Clock is the part of the time package that depends on the passage of time.
+// Code calling a Clock instead of time.Sleep, time.After... can be tested with a Fake clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep pauses the current goroutine for at least d.
+	Sleep(d time.Duration)
+	// After returns a channel receiving the current time after d.
+	After(d time.Duration) <-chan time.Time
+	// Tick returns a channel receiving the current time every d. Like time.Tick, the ticker can't be stopped:
+	// use NewTicker when it must not outlive the code using it.
+	Tick(d time.Duration) <-chan time.Time
+	// NewTimer returns a Timer firing after d.
+	NewTimer(d time.Duration) Timer
+	// NewTicker returns a Ticker delivering the current time every d, which must be positive.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the part of *time.Timer that time-based code uses, so that clocks can provide their own timers.
+type Timer interface {
+	// C returns the channel on which the time is delivered when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, it returns false if it already fired or was stopped.
+	Stop() bool
+	// Reset changes the timer to fire after d, it returns false if the timer had fired or been stopped.
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the part of *time.Ticker that time-based code uses.
+type Ticker interface {
+	// C returns the channel on which the ticks are delivered.
+	C() <-chan time.Time
+	// Stop turns the ticker off, no more ticks are delivered after it returns.
+	Stop()
+	// Reset stops the ticker and resets its period to d, the next tick arrives after d.
+	Reset(d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Tick(d time.Duration) <-chan time.Time {
+	return time.Tick(d)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time   { return t.t.C }
+func (t realTicker) Stop()                 { t.t.Stop() }
+func (t realTicker) Reset(d time.Duration) { t.t.Reset(d) }
+
+// Real is the Clock backed by the time package.
+var Real Clock = realClock{}
+
+// Or returns c, or Real if c is nil. It lets constructors accept a nil Clock.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
@@ -0,0 +1,59 @@
+package clock_test
+
+import (
+	"fmt"
+	"time"
+
+	"golang-demo/clock"
+)
+
+// clockBoom is ClockBoom of main/14-goroutines.go with a boom at 450ms and naps of 70ms,
+// so that no two deadlines coincide and the output is the same on every run.
+func clockBoom(clk clock.Clock) {
+	ticker := clk.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	boom := clk.After(450 * time.Millisecond)
+	for {
+		select {
+		case <-ticker.C():
+			fmt.Println("tick.")
+		case <-boom:
+			fmt.Println("BOOM!")
+			return
+		default:
+			fmt.Println("    .")
+			clk.Sleep(70 * time.Millisecond)
+		}
+	}
+}
+
+// ClockBoom driven by a fake clock: it runs instantly, and BlockUntil makes each step start
+// only once it sleeps, with its ticker and boom timer pending.
+func ExampleFake() {
+	f := clock.NewFake(time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+	done := make(chan struct{})
+	go func() {
+		clockBoom(f)
+		close(done)
+	}()
+	for i := 0; i < 7; i++ {
+		f.BlockUntil(3)
+		f.Advance(70 * time.Millisecond)
+	}
+	<-done
+	fmt.Println("pending:", f.Pending())
+	// Output:
+	//     .
+	//     .
+	// tick.
+	//     .
+	// tick.
+	//     .
+	//     .
+	// tick.
+	//     .
+	// tick.
+	//     .
+	// BOOM!
+	// pending: 0
+}
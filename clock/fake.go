@@ -0,0 +1,225 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when Advance or Set is called.
+// Sleeping goroutines, timers and tickers wake up when the fake time reaches their deadline,
+// so code depending on time runs deterministically and without actually waiting.
+type Fake struct {
+	mux    sync.Mutex
+	cond   *sync.Cond // broadcast when timers are added or removed
+	now    time.Time
+	timers []*fakeTimer // active timers
+}
+
+// NewFake returns a fake clock set to t.
+func NewFake(t time.Time) *Fake {
+	f := &Fake{now: t}
+	f.cond = sync.NewCond(&f.mux)
+	return f
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.now
+}
+
+// Sleep blocks until the fake time has moved forward by d.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// After returns a channel receiving the fake time once it has moved forward by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// Tick returns a channel receiving the fake time every d. Like time.Tick, ticks are dropped for slow receivers.
+// The ticker can't be stopped, it stays pending for good: use NewTicker when it matters, e.g. with BlockUntil.
+func (f *Fake) Tick(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return f.newTimer(d, d).c
+}
+
+// NewTicker returns a Ticker delivering the fake time every d. It panics if d <= 0, like time.NewTicker.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.newTimer(d, d)}
+}
+
+// NewTimer returns a Timer firing once the fake time has moved forward by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.newTimer(d, 0)
+}
+
+func (f *Fake) newTimer(d, period time.Duration) *fakeTimer {
+	t := &fakeTimer{f: f, c: make(chan time.Time, 1), period: period}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	t.start(d)
+	return t
+}
+
+// Advance moves the fake time forward by d, firing the timers whose deadline is reached, in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the fake time to t, firing the timers whose deadline is reached, in order.
+// Time never goes backward: a t before the current fake time is ignored.
+func (f *Fake) Set(t time.Time) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	for {
+		sort.Slice(f.timers, func(i, j int) bool { return f.timers[i].at.Before(f.timers[j].at) })
+		if len(f.timers) == 0 || f.timers[0].at.After(t) {
+			break
+		}
+		next := f.timers[0]
+		if next.at.After(f.now) {
+			f.now = next.at
+		}
+		next.fire()
+	}
+	if t.After(f.now) {
+		f.now = t
+	}
+}
+
+// Pending returns the number of active timers, tickers and sleeping goroutines.
+func (f *Fake) Pending() int {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return len(f.timers)
+}
+
+// BlockUntil waits until at least n timers, tickers or sleeping goroutines are pending.
+// Tests use it to make sure the code under test is waiting before they Advance the clock.
+func (f *Fake) BlockUntil(n int) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	for len(f.timers) < n {
+		f.cond.Wait()
+	}
+}
+
+// fakeTimer is a Timer (or ticker if period > 0) of a Fake clock. Its fields are guarded by f.mux.
+type fakeTimer struct {
+	f      *Fake
+	c      chan time.Time
+	at     time.Time
+	period time.Duration
+	active bool
+}
+
+// start (re)arms the timer to fire after d, f.mux must be held.
+func (t *fakeTimer) start(d time.Duration) {
+	t.at = t.f.now.Add(d)
+	if d <= 0 && t.period == 0 {
+		t.send()
+		return
+	}
+	t.active = true
+	t.f.timers = append(t.f.timers, t)
+	t.f.cond.Broadcast()
+}
+
+// fire delivers the current time, then rearms a ticker or removes a timer, f.mux must be held.
+func (t *fakeTimer) fire() {
+	t.send()
+	if t.period > 0 {
+		t.at = t.at.Add(t.period)
+		return
+	}
+	t.remove()
+}
+
+func (t *fakeTimer) send() {
+	select {
+	case t.c <- t.f.now:
+	default:
+	}
+}
+
+// remove deactivates the timer, f.mux must be held.
+func (t *fakeTimer) remove() bool {
+	if !t.active {
+		return false
+	}
+	t.active = false
+	for i, other := range t.f.timers {
+		if other == t {
+			t.f.timers = append(t.f.timers[:i], t.f.timers[i+1:]...)
+			break
+		}
+	}
+	t.f.cond.Broadcast()
+	return true
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop deactivates the timer. Like time.Timer since Go 1.23, no stale time is received after Stop returns.
+func (t *fakeTimer) Stop() bool {
+	t.f.mux.Lock()
+	defer t.f.mux.Unlock()
+	t.drain()
+	return t.remove()
+}
+
+// Reset rearms the timer to fire after d, discarding any time not received yet.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.f.mux.Lock()
+	defer t.f.mux.Unlock()
+	t.drain()
+	wasActive := t.remove()
+	t.start(d)
+	return wasActive
+}
+
+func (t *fakeTimer) drain() {
+	select {
+	case <-t.c:
+	default:
+	}
+}
+
+// fakeTicker is the Ticker view of a periodic fakeTimer.
+type fakeTicker struct {
+	t *fakeTimer
+}
+
+func (t fakeTicker) C() <-chan time.Time {
+	return t.t.c
+}
+
+// Stop turns the ticker off. A tick delivered but not received yet is discarded, as by time.Ticker since Go 1.23.
+func (t fakeTicker) Stop() {
+	t.t.Stop()
+}
+
+// Reset restarts the ticker with period d, the next tick arrives d after the current fake time.
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	f := t.t.f
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	t.t.drain()
+	t.t.remove()
+	t.t.period = d
+	t.t.start(d)
+}
@@ -0,0 +1,238 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// received returns the value waiting in c, if any, without blocking.
+func received(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-c:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeAdvanceOrder(t *testing.T) {
+	f := NewFake(epoch)
+	// Timers created out of order fire by deadline, only those due by the new time.
+	delays := []time.Duration{30 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}
+	timers := make([]Timer, len(delays))
+	for i, d := range delays {
+		timers[i] = f.NewTimer(d)
+	}
+	f.Advance(25 * time.Millisecond)
+	for i, d := range delays {
+		at, ok := received(timers[i].C())
+		if want := d <= 25*time.Millisecond; ok != want {
+			t.Errorf("timer %v: fired = %v after 25ms", d, ok)
+		}
+		// The time delivered is the deadline, not the time Advance moved to.
+		if ok && !at.Equal(epoch.Add(d)) {
+			t.Errorf("timer %v: got %v, want %v", d, at, epoch.Add(d))
+		}
+	}
+	if now := f.Now(); !now.Equal(epoch.Add(25 * time.Millisecond)) {
+		t.Errorf("Now: got %v", now)
+	}
+	if n := f.Pending(); n != 2 {
+		t.Errorf("Pending: got %d, want 2", n)
+	}
+	f.Advance(time.Second)
+	for i, d := range delays {
+		if d > 25*time.Millisecond {
+			if at, ok := received(timers[i].C()); !ok || !at.Equal(epoch.Add(d)) {
+				t.Errorf("timer %v: got %v, %v", d, at, ok)
+			}
+		}
+	}
+
+	// A sleeper woken by an earlier deadline sees the fake time of that deadline.
+	f = NewFake(epoch)
+	woke := make(chan time.Time)
+	go func() {
+		f.Sleep(10 * time.Millisecond)
+		woke <- f.Now()
+	}()
+	f.BlockUntil(1)
+	late := f.NewTimer(20 * time.Millisecond)
+	f.Advance(10 * time.Millisecond)
+	if at := <-woke; !at.Equal(epoch.Add(10 * time.Millisecond)) {
+		t.Errorf("sleeper woke at %v", at)
+	}
+	if _, ok := received(late.C()); ok {
+		t.Error("later timer fired early")
+	}
+}
+
+func TestFakeSetBackward(t *testing.T) {
+	f := NewFake(epoch)
+	f.Set(epoch.Add(-time.Hour))
+	if !f.Now().Equal(epoch) {
+		t.Errorf("time went back to %v", f.Now())
+	}
+}
+
+func TestFakeStopReset(t *testing.T) {
+	f := NewFake(epoch)
+	tm := f.NewTimer(time.Second)
+	if !tm.Stop() {
+		t.Error("Stop of an active timer: got false")
+	}
+	if tm.Stop() {
+		t.Error("second Stop: got true")
+	}
+	f.Advance(2 * time.Second)
+	if _, ok := received(tm.C()); ok {
+		t.Error("stopped timer fired")
+	}
+
+	// Fired but not received: Stop returns false and the stale time is gone.
+	tm = f.NewTimer(time.Second)
+	f.Advance(time.Second)
+	if tm.Stop() {
+		t.Error("Stop of a fired timer: got true")
+	}
+	if _, ok := received(tm.C()); ok {
+		t.Error("stale time received after Stop")
+	}
+
+	// Reset of a fired timer: false, no stale time, and it fires again at the new deadline.
+	tm = f.NewTimer(time.Second)
+	f.Advance(time.Second)
+	if tm.Reset(time.Second) {
+		t.Error("Reset of a fired timer: got true")
+	}
+	if _, ok := received(tm.C()); ok {
+		t.Error("stale time received after Reset")
+	}
+	if !tm.Reset(2 * time.Second) {
+		t.Error("Reset of an active timer: got false")
+	}
+	f.Advance(time.Second)
+	if _, ok := received(tm.C()); ok {
+		t.Error("timer fired at its old deadline")
+	}
+	f.Advance(time.Second)
+	if _, ok := received(tm.C()); !ok {
+		t.Error("timer didn't fire at its new deadline")
+	}
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending: got %d, want 0", n)
+	}
+}
+
+func TestFakeNonPositive(t *testing.T) {
+	f := NewFake(epoch)
+	for _, d := range []time.Duration{0, -time.Second} {
+		if _, ok := received(f.After(d)); !ok {
+			t.Errorf("After(%v) didn't fire right away", d)
+		}
+		tm := f.NewTimer(time.Hour)
+		if !tm.Reset(d) {
+			t.Errorf("Reset(%v): got false", d)
+		}
+		if _, ok := received(tm.C()); !ok {
+			t.Errorf("Reset(%v) didn't fire right away", d)
+		}
+		f.Sleep(d) // returns without Advance
+		if f.Tick(d) != nil {
+			t.Errorf("Tick(%v): got a channel", d)
+		}
+	}
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending: got %d, want 0", n)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("NewTicker(0) didn't panic")
+		}
+	}()
+	f.NewTicker(0)
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(epoch)
+	tk := f.NewTicker(10 * time.Millisecond)
+	// A slow receiver gets the first tick, the others are dropped.
+	f.Advance(100 * time.Millisecond)
+	if at, ok := received(tk.C()); !ok || !at.Equal(epoch.Add(10*time.Millisecond)) {
+		t.Errorf("first tick: got %v, %v", at, ok)
+	}
+	if _, ok := received(tk.C()); ok {
+		t.Error("more than one tick buffered")
+	}
+	f.Advance(10 * time.Millisecond)
+	if at, ok := received(tk.C()); !ok || !at.Equal(epoch.Add(110*time.Millisecond)) {
+		t.Errorf("next tick: got %v, %v", at, ok)
+	}
+
+	tk.Reset(time.Second)
+	f.Advance(500 * time.Millisecond)
+	if _, ok := received(tk.C()); ok {
+		t.Error("tick at the old period after Reset")
+	}
+	f.Advance(500 * time.Millisecond)
+	if _, ok := received(tk.C()); !ok {
+		t.Error("no tick at the new period")
+	}
+
+	f.Advance(time.Second) // a tick is waiting
+	tk.Stop()
+	if _, ok := received(tk.C()); ok {
+		t.Error("stale tick received after Stop")
+	}
+	f.Advance(time.Hour)
+	if _, ok := received(tk.C()); ok {
+		t.Error("tick after Stop")
+	}
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending after Stop: got %d, want 0", n)
+	}
+
+	// Tick can't be stopped: it stays pending.
+	f.Tick(time.Second)
+	if n := f.Pending(); n != 1 {
+		t.Errorf("Pending with Tick: got %d, want 1", n)
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	f := NewFake(epoch)
+	done := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() {
+			f.Sleep(time.Minute)
+			done <- struct{}{}
+		}()
+	}
+	f.BlockUntil(3)
+	select {
+	case <-done:
+		t.Fatal("a sleeper woke up before Advance")
+	default:
+	}
+	f.Advance(time.Minute)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("%d sleepers of 3 woke up", i)
+		}
+	}
+	f.BlockUntil(0) // already true
+}
+
+func TestRealTicker(t *testing.T) {
+	tk := Or(nil).NewTicker(time.Millisecond)
+	defer tk.Stop()
+	select {
+	case <-tk.C():
+	case <-time.After(time.Second):
+		t.Fatal("no tick")
+	}
+}
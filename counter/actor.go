@@ -0,0 +1,108 @@
+package counter
+
+import (
+	"sort"
+	"time"
+
+	"golang-demo/actor"
+)
+
+// ActorCounter counts like SafeCounter, but without a mutex: the map belongs to an actor,
+// a single goroutine, and the other goroutines send it messages to update or read it.
+// "Don't communicate by sharing memory; share memory by communicating."
+type ActorCounter struct {
+	ref     *actor.Ref[counterMsg]
+	timeout time.Duration
+}
+
+// counterMsg is one of the messages below.
+type counterMsg any
+
+type addMsg struct {
+	key   string
+	delta int
+}
+
+type valueMsg struct {
+	key   string
+	reply actor.Reply[int]
+}
+
+type snapshotMsg struct {
+	reply actor.Reply[map[string]int]
+}
+
+// counterActor owns the counts.
+type counterActor struct {
+	v map[string]int
+}
+
+func (a *counterActor) Receive(self *actor.Ref[counterMsg], msg counterMsg) error {
+	switch m := msg.(type) {
+	case addMsg:
+		a.v[m.key] += m.delta
+	case valueMsg:
+		m.reply.Send(a.v[m.key])
+	case snapshotMsg:
+		s := make(map[string]int, len(a.v))
+		for k, n := range a.v {
+			s[k] = n
+		}
+		m.reply.Send(s)
+	}
+	return nil
+}
+
+// NewActorCounter starts an empty ActorCounter, whose reads wait at most timeout for the actor to answer.
+// A nil supervisor is fine: the actor never fails. Stop the counter when done with it.
+func NewActorCounter(sup *actor.Supervisor, timeout time.Duration) *ActorCounter {
+	ref := actor.Spawn(func() actor.Actor[counterMsg] {
+		return &counterActor{v: make(map[string]int)}
+	}, actor.Options{Name: "counter", Mailbox: 64, Supervisor: sup})
+	return &ActorCounter{ref: ref, timeout: timeout}
+}
+
+// Inc increments the counter for the given key.
+func (c *ActorCounter) Inc(key string) error {
+	return c.Add(key, 1)
+}
+
+// Add adds delta to the counter for the given key. It returns once the message is in the mailbox,
+// a later Value from the same goroutine sees it since the actor handles its messages in order.
+func (c *ActorCounter) Add(key string, delta int) error {
+	return c.ref.Send(addMsg{key, delta})
+}
+
+// Value returns the current value of the counter for the given key.
+func (c *ActorCounter) Value(key string) (int, error) {
+	return actor.Ask(c.ref, c.timeout, func(r actor.Reply[int]) counterMsg {
+		return valueMsg{key, r}
+	})
+}
+
+// Snapshot returns a copy of all the counters.
+func (c *ActorCounter) Snapshot() (map[string]int, error) {
+	return actor.Ask(c.ref, c.timeout, func(r actor.Reply[map[string]int]) counterMsg {
+		return snapshotMsg{r}
+	})
+}
+
+// Keys returns the counted keys in sorted order.
+func (c *ActorCounter) Keys() ([]string, error) {
+	m, err := c.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Stop stops the actor, later calls return actor.ErrStopped.
+func (c *ActorCounter) Stop() {
+	c.ref.Stop()
+	<-c.ref.Done()
+}
@@ -0,0 +1,127 @@
+package counter
+
+import "fmt"
+
+// ConflictError is returned when a condition of a Batch (or a CompareAndSet) does not hold.
+// None of the batch's changes are applied then.
+type ConflictError struct {
+	Key   string
+	Value int    // value of the key when the condition was checked
+	Op    string // the failed operation, e.g. "compare-and-set 3 -> 4"
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("counter: %s on %q failed, value is %d", e.Op, e.Key, e.Value)
+}
+
+// Batch is a list of changes applied to a SafeCounter atomically by Apply:
+// other goroutines see either none or all of them.
+// Its methods return the batch, so calls can be chained.
+type Batch struct {
+	ops []batchOp
+}
+
+type batchOp struct {
+	key   string
+	desc  string
+	check func(v int) bool // nil if unconditional
+	apply func(v int) int
+}
+
+// NewBatch returns an empty Batch.
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Inc increments the counter for the given key.
+func (b *Batch) Inc(key string) *Batch {
+	return b.Add(key, 1)
+}
+
+// Add adds delta to the counter for the given key.
+func (b *Batch) Add(key string, delta int) *Batch {
+	b.ops = append(b.ops, batchOp{
+		key:   key,
+		apply: func(v int) int { return v + delta },
+	})
+	return b
+}
+
+// Set sets the counter for the given key.
+func (b *Batch) Set(key string, value int) *Batch {
+	b.ops = append(b.ops, batchOp{
+		key:   key,
+		apply: func(int) int { return value },
+	})
+	return b
+}
+
+// CompareAndSet sets the counter for the given key to new if it is old, otherwise the batch fails.
+func (b *Batch) CompareAndSet(key string, old, new int) *Batch {
+	b.ops = append(b.ops, batchOp{
+		key:   key,
+		desc:  fmt.Sprintf("compare-and-set %d -> %d", old, new),
+		check: func(v int) bool { return v == old },
+		apply: func(int) int { return new },
+	})
+	return b
+}
+
+// AddIf adds delta to the counter for the given key if cond holds for its value, otherwise the batch fails.
+// For instance `AddIf("stock", -1, func(v int) bool { return v > 0 })`.
+// cond runs while the counter is locked, it must not call the counter.
+func (b *Batch) AddIf(key string, delta int, cond func(v int) bool) *Batch {
+	b.ops = append(b.ops, batchOp{
+		key:   key,
+		desc:  fmt.Sprintf("conditional add %d", delta),
+		check: cond,
+		apply: func(v int) int { return v + delta },
+	})
+	return b
+}
+
+// Len returns the number of changes in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Apply applies all the changes of b in order, or none of them if a condition fails, in which case
+// the error is a *ConflictError. Conditions see the changes made earlier in the same batch.
+func (c *SafeCounter) Apply(b *Batch) error {
+	c.lock()
+	defer c.unlock()
+	staged := make(map[string]int, len(b.ops))
+	for _, op := range b.ops {
+		v, ok := staged[op.key]
+		if !ok {
+			v = c.v[op.key]
+		}
+		if op.check != nil && !op.check(v) {
+			return &ConflictError{Key: op.key, Value: v, Op: op.desc}
+		}
+		staged[op.key] = op.apply(v)
+	}
+	if c.v == nil {
+		c.v = make(map[string]int, len(staged))
+	}
+	for k, v := range staged {
+		c.v[k] = v
+	}
+	return nil
+}
+
+// CompareAndSet sets the counter for the given key to new if it is old, and reports whether it did.
+func (c *SafeCounter) CompareAndSet(key string, old, new int) bool {
+	return c.Apply(NewBatch().CompareAndSet(key, old, new)) == nil
+}
+
+// Values returns the values of the given keys, read consistently at the same instant.
+func (c *SafeCounter) Values(keys ...string) map[string]int {
+	c.lock()
+	defer c.unlock()
+	m := make(map[string]int, len(keys))
+	for _, k := range keys {
+		m[k] = c.v[k]
+	}
+	return m
+}
@@ -0,0 +1,127 @@
+package counter
+
+import (
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	positive := func(v int) bool { return v > 0 }
+	tests := []struct {
+		name     string
+		batch    *Batch
+		want     map[string]int
+		conflict *ConflictError // nil if the batch applies
+	}{
+		{"unconditional", NewBatch().Inc("a").Add("b", -2).Set("c", 7), map[string]int{"a": 2, "b": -2, "c": 7}, nil},
+		{"empty", NewBatch(), map[string]int{"a": 1}, nil},
+		{"add if", NewBatch().AddIf("a", -1, positive).Inc("orders"), map[string]int{"a": 0, "orders": 1}, nil},
+		// The second AddIf sees the first one's change: a is 0 by then.
+		{"add if twice", NewBatch().AddIf("a", -1, positive).AddIf("a", -1, positive).Inc("orders"),
+			map[string]int{"a": 1}, &ConflictError{Key: "a", Value: 0, Op: "conditional add -1"}},
+		{"compare and set", NewBatch().CompareAndSet("a", 1, 5).Inc("a"), map[string]int{"a": 6}, nil},
+		// The changes before the failed condition are not applied either.
+		{"compare and set fails", NewBatch().Inc("b").Set("a", 3).CompareAndSet("a", 1, 5),
+			map[string]int{"a": 1}, &ConflictError{Key: "a", Value: 3, Op: "compare-and-set 1 -> 5"}},
+		{"missing key is 0", NewBatch().CompareAndSet("new", 0, 1), map[string]int{"a": 1, "new": 1}, nil},
+	}
+	for _, tt := range tests {
+		c := NewSafeCounter()
+		c.Inc("a")
+		err := c.Apply(tt.batch)
+		if tt.conflict == nil {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+		} else {
+			var ce *ConflictError
+			if !errors.As(err, &ce) || *ce != *tt.conflict {
+				t.Errorf("%s: got %v, want %v", tt.name, err, tt.conflict)
+			}
+		}
+		if got := c.Snapshot(); !maps.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A zero SafeCounter works too.
+	var c SafeCounter
+	if err := c.Apply(NewBatch().Inc("a")); err != nil || c.Value("a") != 1 {
+		t.Errorf("zero counter: got %d, %v", c.Value("a"), err)
+	}
+}
+
+func TestConflictError(t *testing.T) {
+	err := error(&ConflictError{Key: "apples", Value: 1, Op: "conditional add -2"})
+	if want := `counter: conditional add -2 on "apples" failed, value is 1`; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
+
+// Transfers between two keys: the sum never changes, whatever the reader sees, and no key goes negative.
+func TestApplyAtomic(t *testing.T) {
+	const total = 100
+	c := NewSafeCounter()
+	c.Add("a", total)
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	transfer := func(from, to string) {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			err := c.Apply(NewBatch().AddIf(from, -1, func(v int) bool { return v > 0 }).Inc(to))
+			var ce *ConflictError
+			if err != nil && !errors.As(err, &ce) {
+				t.Errorf("transfer: %v", err)
+			}
+		}
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go transfer("a", "b")
+		go transfer("b", "a")
+	}
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			v := c.Values("a", "b")
+			if v["a"]+v["b"] != total || v["a"] < 0 || v["b"] < 0 {
+				t.Errorf("inconsistent read: %v", v)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	<-readerDone
+	if v := c.Values("a", "b"); v["a"]+v["b"] != total {
+		t.Errorf("got %v, want a sum of %d", v, total)
+	}
+}
+
+// CompareAndSet in a retry loop makes a lock-free style increment.
+func TestCompareAndSet(t *testing.T) {
+	c := NewSafeCounter()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for v := c.Value("n"); !c.CompareAndSet("n", v, v+1); v = c.Value("n") {
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.Value("n"); n != 800 {
+		t.Errorf("got %d, want 800", n)
+	}
+}
@@ -0,0 +1,245 @@
+package counter
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// GCounter is a grow-only counter replicated across processes (a state-based CRDT).
+// Every replica counts its own increments per key, and replicas exchange their state and Merge it.
+// Merging is commutative, associative and idempotent, so replicas that have seen the same states
+// agree on every Value whatever the order or number of times the states were merged.
+type GCounter struct {
+	replica string
+	v       map[string]map[string]int // key -> replica -> count
+	mux     sync.Mutex
+}
+
+// NewGCounter returns an empty counter for the given replica ID, which must be unique among the replicas.
+func NewGCounter(replica string) *GCounter {
+	return &GCounter{replica: replica, v: make(map[string]map[string]int)}
+}
+
+// Replica returns the ID of the replica owning the counter.
+func (c *GCounter) Replica() string {
+	return c.replica
+}
+
+// Inc increments the counter for the given key.
+func (c *GCounter) Inc(key string) {
+	c.Add(key, 1)
+}
+
+// Add adds n to the counter for the given key. A GCounter only grows, n must not be negative.
+func (c *GCounter) Add(key string, n int) {
+	if n < 0 {
+		panic("counter: negative count added to a GCounter")
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	replicas, ok := c.v[key]
+	if !ok {
+		replicas = make(map[string]int)
+		c.v[key] = replicas
+	}
+	replicas[c.replica] += n
+}
+
+// Value returns the current value of the counter for the given key, summed over all the replicas.
+func (c *GCounter) Value(key string) int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	total := 0
+	for _, n := range c.v[key] {
+		total += n
+	}
+	return total
+}
+
+// Snapshot returns the value of every key.
+func (c *GCounter) Snapshot() map[string]int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	m := make(map[string]int, len(c.v))
+	for key, replicas := range c.v {
+		for _, n := range replicas {
+			m[key] += n
+		}
+	}
+	return m
+}
+
+// state returns a copy of the per-replica counts.
+func (c *GCounter) state() map[string]map[string]int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	state := make(map[string]map[string]int, len(c.v))
+	for key, replicas := range c.v {
+		copied := make(map[string]int, len(replicas))
+		for r, n := range replicas {
+			copied[r] = n
+		}
+		state[key] = copied
+	}
+	return state
+}
+
+// Merge folds the state of other into c: each replica's count becomes the larger of the two.
+func (c *GCounter) Merge(other *GCounter) {
+	state := other.state()
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.merge(state)
+}
+
+func (c *GCounter) merge(state map[string]map[string]int) {
+	for key, theirs := range state {
+		ours, ok := c.v[key]
+		if !ok {
+			ours = make(map[string]int, len(theirs))
+			c.v[key] = ours
+		}
+		for r, n := range theirs {
+			if n > ours[r] {
+				ours[r] = n
+			}
+		}
+	}
+}
+
+// gcounterJSON is the serialized form of a GCounter.
+type gcounterJSON struct {
+	Replica string                    `json:"replica"`
+	Counts  map[string]map[string]int `json:"counts"`
+}
+
+// MarshalJSON encodes the replica ID and the per-replica counts, ready to be sent to other replicas.
+func (c *GCounter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gcounterJSON{c.replica, c.state()})
+}
+
+// UnmarshalJSON restores a counter encoded by MarshalJSON. Decode into a new GCounter, then Merge it.
+func (c *GCounter) UnmarshalJSON(data []byte) error {
+	var s gcounterJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, replicas := range s.Counts {
+		for _, n := range replicas {
+			if n < 0 {
+				return ErrBadSnapshot
+			}
+		}
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.replica = s.Replica
+	c.v = make(map[string]map[string]int, len(s.Counts))
+	c.merge(s.Counts)
+	return nil
+}
+
+// PNCounter is a replicated counter that can also be decremented.
+// It is made of two GCounters: one for the increments and one for the decrements.
+type PNCounter struct {
+	mux sync.RWMutex // guards the p and n pointers, replaced by UnmarshalJSON
+	p   *GCounter
+	n   *GCounter
+}
+
+// NewPNCounter returns an empty counter for the given replica ID, which must be unique among the replicas.
+func NewPNCounter(replica string) *PNCounter {
+	return &PNCounter{p: NewGCounter(replica), n: NewGCounter(replica)}
+}
+
+// halves returns the increments and decrements counters.
+func (c *PNCounter) halves() (p, n *GCounter) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.p, c.n
+}
+
+// Replica returns the ID of the replica owning the counter.
+func (c *PNCounter) Replica() string {
+	p, _ := c.halves()
+	return p.Replica()
+}
+
+// Inc increments the counter for the given key.
+func (c *PNCounter) Inc(key string) {
+	c.Add(key, 1)
+}
+
+// Dec decrements the counter for the given key.
+func (c *PNCounter) Dec(key string) {
+	c.Add(key, -1)
+}
+
+// Add adds delta, which may be negative, to the counter for the given key.
+func (c *PNCounter) Add(key string, delta int) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	if delta < 0 {
+		c.n.Add(key, -delta)
+	} else {
+		c.p.Add(key, delta)
+	}
+}
+
+// Value returns the current value of the counter for the given key.
+func (c *PNCounter) Value(key string) int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.p.Value(key) - c.n.Value(key)
+}
+
+// Snapshot returns the value of every key.
+func (c *PNCounter) Snapshot() map[string]int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	m := c.p.Snapshot()
+	for key, n := range c.n.Snapshot() {
+		m[key] -= n
+	}
+	return m
+}
+
+// Merge folds the state of other into c.
+func (c *PNCounter) Merge(other *PNCounter) {
+	// Read the halves of other first: holding both counters' locks at once could deadlock
+	// with a concurrent other.Merge(c).
+	op, on := other.halves()
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	c.p.Merge(op)
+	c.n.Merge(on)
+}
+
+type pncounterJSON struct {
+	P *GCounter `json:"p"`
+	N *GCounter `json:"n"`
+}
+
+// MarshalJSON encodes the increments and decrements of every replica.
+func (c *PNCounter) MarshalJSON() ([]byte, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return json.Marshal(pncounterJSON{c.p, c.n})
+}
+
+// UnmarshalJSON restores a counter encoded by MarshalJSON. It replaces the whole state of c at once,
+// so it is safe even while other goroutines use c, although decoding into a new PNCounter then
+// calling Merge is the usual way to take in the state of another replica.
+func (c *PNCounter) UnmarshalJSON(data []byte) error {
+	s := pncounterJSON{P: NewGCounter(""), N: NewGCounter("")}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.P == nil || s.N == nil {
+		return ErrBadSnapshot
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.p, c.n = s.P, s.N
+	return nil
+}
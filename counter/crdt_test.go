@@ -0,0 +1,200 @@
+package counter
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+var crdtKeys = []string{"a", "b", "c"}
+
+// sameValues compares two snapshots, a missing key counting as 0.
+func sameValues(a, b map[string]int) bool {
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	for k, v := range b {
+		if a[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// randomPN returns n replicas with random updates, and the values they should converge to.
+func randomPN(rng *rand.Rand, n int) ([]*PNCounter, map[string]int) {
+	want := map[string]int{}
+	replicas := make([]*PNCounter, n)
+	for i := range replicas {
+		replicas[i] = NewPNCounter(fmt.Sprint("r", i))
+		for j := rng.Intn(20); j > 0; j-- {
+			key := crdtKeys[rng.Intn(len(crdtKeys))]
+			delta := rng.Intn(11) - 5
+			replicas[i].Add(key, delta)
+			want[key] += delta
+		}
+	}
+	return replicas, want
+}
+
+// clonePN returns a copy of c, owned by the same replica.
+func clonePN(c *PNCounter) *PNCounter {
+	d := NewPNCounter(c.Replica())
+	d.Merge(c)
+	return d
+}
+
+// merged returns a copy of a with b merged into it.
+func merged(a, b *PNCounter) *PNCounter {
+	d := clonePN(a)
+	d.Merge(b)
+	return d
+}
+
+func TestPNCounterMergeLaws(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		r, _ := randomPN(rng, 3)
+		a, b, c := r[0], r[1], r[2]
+		if ab, ba := merged(a, b).Snapshot(), merged(b, a).Snapshot(); !sameValues(ab, ba) {
+			t.Fatalf("not commutative: a⊔b = %v, b⊔a = %v", ab, ba)
+		}
+		if l, r := merged(merged(a, b), c).Snapshot(), merged(a, merged(b, c)).Snapshot(); !sameValues(l, r) {
+			t.Fatalf("not associative: (a⊔b)⊔c = %v, a⊔(b⊔c) = %v", l, r)
+		}
+		if aa, a1 := merged(a, a).Snapshot(), a.Snapshot(); !sameValues(aa, a1) {
+			t.Fatalf("not idempotent: a⊔a = %v, a = %v", aa, a1)
+		}
+	}
+}
+
+func TestPNCounterConvergence(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 100; i++ {
+		replicas, want := randomPN(rng, 2+rng.Intn(4))
+		// Every replica receives the state of every other one, in its own shuffled order,
+		// some states more than once, and some states of replicas that already merged others.
+		states := make([]*PNCounter, 0, 2*len(replicas))
+		for _, r := range replicas {
+			states = append(states, clonePN(r))
+		}
+		for _, r := range replicas {
+			order := append(rng.Perm(len(states)), rng.Perm(len(states))[:rng.Intn(len(states))]...)
+			for _, j := range order {
+				r.Merge(states[j])
+			}
+			if rng.Intn(2) == 0 {
+				states = append(states, clonePN(r))
+			}
+		}
+		for _, r := range replicas {
+			if got := r.Snapshot(); !sameValues(got, want) {
+				t.Fatalf("replica %s: got %v, want %v", r.Replica(), got, want)
+			}
+		}
+	}
+}
+
+func TestGCounterConvergence(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 100; i++ {
+		want := map[string]int{}
+		replicas := make([]*GCounter, 2+rng.Intn(4))
+		for j := range replicas {
+			replicas[j] = NewGCounter(fmt.Sprint("r", j))
+			for k := rng.Intn(20); k > 0; k-- {
+				key := crdtKeys[rng.Intn(len(crdtKeys))]
+				n := rng.Intn(5)
+				replicas[j].Add(key, n)
+				want[key] += n
+			}
+		}
+		// Replicas merge in place, so some receive states that already hold merges of others.
+		for _, r := range replicas {
+			for _, j := range append(rng.Perm(len(replicas)), rng.Perm(len(replicas))...) {
+				r.Merge(replicas[j])
+			}
+		}
+		for _, r := range replicas {
+			if got := r.Snapshot(); !sameValues(got, want) {
+				t.Fatalf("replica %s: got %v, want %v", r.Replica(), got, want)
+			}
+		}
+	}
+}
+
+func TestCRDTJSONRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	replicas, _ := randomPN(rng, 3)
+	c := replicas[0]
+	c.Merge(replicas[1])
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d PNCounter
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Replica() != c.Replica() || !maps.Equal(d.Snapshot(), c.Snapshot()) {
+		t.Fatalf("got %s %v, want %s %v", d.Replica(), d.Snapshot(), c.Replica(), c.Snapshot())
+	}
+	// The decoded state still merges like the original: nothing of the per-replica counts is lost.
+	if got, want := merged(&d, replicas[2]).Snapshot(), merged(c, replicas[2]).Snapshot(); !sameValues(got, want) {
+		t.Fatalf("after merge: got %v, want %v", got, want)
+	}
+
+	g := NewGCounter("g")
+	g.Add("a", 3)
+	g.Merge(func() *GCounter { o := NewGCounter("h"); o.Add("a", 2); return o }())
+	data, err = json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h GCounter
+	if err := json.Unmarshal(data, &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Replica() != "g" || h.Value("a") != 5 {
+		t.Fatalf("got %s %v, want g 5", h.Replica(), h.Value("a"))
+	}
+
+	if err := json.Unmarshal([]byte(`{"replica":"x","counts":{"a":{"x":-1}}}`), &h); err != ErrBadSnapshot {
+		t.Fatalf("negative count: got %v, want ErrBadSnapshot", err)
+	}
+	if err := json.Unmarshal([]byte(`{"p":null,"n":null}`), &d); err != ErrBadSnapshot {
+		t.Fatalf("missing halves: got %v, want ErrBadSnapshot", err)
+	}
+}
+
+// TestPNCounterUnmarshalConcurrent is meant for the race detector: decoding into a counter in use
+// must not race with its readers and writers.
+func TestPNCounterUnmarshalConcurrent(t *testing.T) {
+	c := NewPNCounter("r")
+	data, _ := json.Marshal(NewPNCounter("r"))
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			c.Inc("a")
+			c.Value("a")
+			c.Merge(NewPNCounter("s"))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if err := json.Unmarshal(data, c); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,224 @@
+package counter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Format is the encoding of a SafeCounter snapshot.
+type Format int
+
+const (
+	// JSON encodes the snapshot as a JSON object mapping keys to values.
+	JSON Format = iota
+	// Binary encodes the snapshot compactly: a magic header, then varint-encoded entries.
+	Binary
+)
+
+// binaryMagic starts every Binary snapshot, it is also how Decode tells the formats apart.
+var binaryMagic = []byte("SCNT\x01")
+
+// ErrBadSnapshot is returned when a snapshot cannot be decoded.
+var ErrBadSnapshot = errors.New("counter: malformed snapshot")
+
+// Encode writes a snapshot of the counters to w in the given format.
+func (c *SafeCounter) Encode(w io.Writer, f Format) error {
+	m := c.Snapshot()
+	switch f {
+	case JSON:
+		return json.NewEncoder(w).Encode(m)
+	case Binary:
+		return encodeBinary(w, m)
+	}
+	return fmt.Errorf("counter: unknown format %d", f)
+}
+
+func encodeBinary(w io.Writer, m map[string]int) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, binary.MaxVarintLen64)
+	bw.Write(binaryMagic)
+	bw.Write(buf[:binary.PutUvarint(buf, uint64(len(keys)))])
+	for _, k := range keys {
+		bw.Write(buf[:binary.PutUvarint(buf, uint64(len(k)))])
+		bw.WriteString(k)
+		bw.Write(buf[:binary.PutVarint(buf, int64(m[k]))])
+	}
+	// bufio.Writer keeps the first error, Flush reports it.
+	return bw.Flush()
+}
+
+// Decode replaces the counters with a snapshot read from r. The format is detected from the content.
+func (c *SafeCounter) Decode(r io.Reader) error {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(binaryMagic))
+	var m map[string]int
+	var err error
+	if bytes.Equal(head, binaryMagic) {
+		br.Discard(len(binaryMagic))
+		m, err = decodeBinary(br)
+	} else {
+		err = json.NewDecoder(br).Decode(&m)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSnapshot, err)
+	}
+	c.Restore(m)
+	return nil
+}
+
+func decodeBinary(r *bufio.Reader) (map[string]int, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]int)
+	for i := uint64(0); i < n; i++ {
+		size, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if size > 1<<20 {
+			return nil, fmt.Errorf("key of %d bytes", size)
+		}
+		key := make([]byte, size)
+		if _, err := io.ReadFull(r, key); err != nil {
+			return nil, err
+		}
+		v, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, err
+		}
+		m[string(key)] = int(v)
+	}
+	return m, nil
+}
+
+// Save writes a snapshot of the counters to the file at path.
+// The snapshot goes to a temporary file first which then replaces path, so a crash never leaves a half-written file.
+func (c *SafeCounter) Save(path string, f Format) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	// Clean up on failure, a no-op once the file has been renamed.
+	defer os.Remove(tmp.Name())
+
+	if err := c.Encode(tmp, f); err != nil {
+		tmp.Close()
+		return err
+	}
+	// Make sure the content is on disk before the rename makes it visible.
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load returns a SafeCounter restored from the snapshot at path, or an empty one if the file does not exist yet.
+func Load(path string) (*SafeCounter, error) {
+	c := NewSafeCounter()
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err := c.Decode(file); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Flusher saves a SafeCounter to disk periodically in the background.
+type Flusher struct {
+	c      *SafeCounter
+	path   string
+	format Format
+	stop   chan struct{}
+	done   chan struct{}
+	mux    sync.Mutex
+	err    error
+
+	stopOnce sync.Once
+	stopErr  error // the result of the final save
+}
+
+// FlushEvery starts saving the counters to path every interval until Stop is called.
+// With an interval <= 0 there are no periodic saves, the counters are only saved by Stop.
+func (c *SafeCounter) FlushEvery(path string, f Format, interval time.Duration) *Flusher {
+	fl := &Flusher{
+		c:      c,
+		path:   path,
+		format: f,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go fl.run(interval)
+	return fl
+}
+
+func (fl *Flusher) run(interval time.Duration) {
+	defer close(fl.done)
+	if interval <= 0 {
+		<-fl.stop
+		return
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			fl.flush()
+		case <-fl.stop:
+			return
+		}
+	}
+}
+
+func (fl *Flusher) flush() error {
+	err := fl.c.Save(fl.path, fl.format)
+	fl.mux.Lock()
+	fl.err = err
+	fl.mux.Unlock()
+	return err
+}
+
+// Err returns the error of the last background save, if any.
+func (fl *Flusher) Err() error {
+	fl.mux.Lock()
+	defer fl.mux.Unlock()
+	return fl.err
+}
+
+// Stop stops the background saves and saves the counters one last time.
+// Calling it again does nothing but return the error of that last save.
+func (fl *Flusher) Stop() error {
+	fl.stopOnce.Do(func() {
+		close(fl.stop)
+		<-fl.done
+		fl.stopErr = fl.flush()
+	})
+	return fl.stopErr
+}
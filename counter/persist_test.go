@@ -0,0 +1,172 @@
+package counter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func sampleCounter() *SafeCounter {
+	c := NewSafeCounter()
+	c.Add("a", 3)
+	c.Add("b", -7)
+	c.Add("", 1)
+	c.Add("clé ✓", 1<<40)
+	return c
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, f := range []Format{JSON, Binary} {
+		for _, c := range []*SafeCounter{sampleCounter(), NewSafeCounter()} {
+			var buf bytes.Buffer
+			if err := c.Encode(&buf, f); err != nil {
+				t.Fatalf("format %d: Encode: %v", f, err)
+			}
+			d := NewSafeCounter()
+			d.Inc("stale") // replaced by the snapshot
+			if err := d.Decode(&buf); err != nil {
+				t.Fatalf("format %d: Decode: %v", f, err)
+			}
+			if got, want := d.Snapshot(), c.Snapshot(); !maps.Equal(got, want) {
+				t.Errorf("format %d: got %v, want %v", f, got, want)
+			}
+		}
+	}
+	if err := NewSafeCounter().Encode(new(bytes.Buffer), Format(42)); err == nil {
+		t.Error("unknown format: got no error")
+	}
+}
+
+func TestDecodeBad(t *testing.T) {
+	var bin, js bytes.Buffer
+	sampleCounter().Encode(&bin, Binary)
+	sampleCounter().Encode(&js, JSON)
+
+	inputs := map[string][]byte{
+		"empty":     nil,
+		"garbage":   []byte("\x00\x01garbage"),
+		"json list": []byte(`[1, 2]`),
+		"json text": []byte(`{"a": "x"}`),
+		"huge key":  append(append([]byte{}, binaryMagic...), 1, 0xff, 0xff, 0xff, 0x0f),
+	}
+	// Every truncation of a valid snapshot is malformed.
+	for n := 0; n < bin.Len(); n++ {
+		inputs[fmt.Sprintf("binary truncated to %d bytes", n)] = bin.Bytes()[:n]
+	}
+	for n := 0; n < js.Len()-2; n++ { // without the closing brace
+		inputs[fmt.Sprintf("json truncated to %d bytes", n)] = js.Bytes()[:n]
+	}
+	for name, in := range inputs {
+		c := NewSafeCounter()
+		c.Inc("kept")
+		err := c.Decode(bytes.NewReader(in))
+		if !errors.Is(err, ErrBadSnapshot) {
+			t.Errorf("%s: got %v, want ErrBadSnapshot", name, err)
+		}
+		// A failed Decode leaves the counters alone.
+		if got := c.Snapshot(); !maps.Equal(got, map[string]int{"kept": 1}) {
+			t.Errorf("%s: counters changed to %v", name, got)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []Format{JSON, Binary} {
+		path := filepath.Join(dir, "counts")
+		c := sampleCounter()
+		if err := c.Save(path, f); err != nil {
+			t.Fatal(err)
+		}
+		// Saving again replaces the file.
+		c.Inc("a")
+		if err := c.Save(path, f); err != nil {
+			t.Fatal(err)
+		}
+		d, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := d.Snapshot(), c.Snapshot(); !maps.Equal(got, want) {
+			t.Errorf("format %d: got %v, want %v", f, got, want)
+		}
+	}
+	// No temporary file is left behind.
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("got %d files, want 1", len(entries))
+	}
+
+	c, err := Load(filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+	if len(c.Keys()) != 0 {
+		t.Errorf("missing file: got %v, want an empty counter", c.Snapshot())
+	}
+
+	bad := filepath.Join(dir, "bad")
+	os.WriteFile(bad, []byte("SCNT\x01\x05"), 0o644)
+	if _, err := Load(bad); !errors.Is(err, ErrBadSnapshot) {
+		t.Errorf("bad file: got %v, want ErrBadSnapshot", err)
+	}
+}
+
+func TestFlusher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "counts")
+	c := NewSafeCounter()
+	c.Inc("a")
+	fl := c.FlushEvery(path, JSON, 5*time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if d, err := Load(path); err == nil && d.Value("a") == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no periodic save")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Inc("a")
+	if err := fl.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fl.Stop(); err != nil {
+		t.Fatalf("second Stop: %v", err)
+	}
+	if d, _ := Load(path); d.Value("a") != 2 {
+		t.Errorf("after Stop: got %d, want 2", d.Value("a"))
+	}
+	if err := fl.Err(); err != nil {
+		t.Errorf("Err: %v", err)
+	}
+}
+
+func TestFlusherNoInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counts")
+	c := NewSafeCounter()
+	c.Inc("a")
+	fl := c.FlushEvery(path, Binary, 0)
+	time.Sleep(10 * time.Millisecond)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("saved before Stop: %v", err)
+	}
+	if err := fl.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := Load(path); d.Value("a") != 1 {
+		t.Errorf("after Stop: got %d, want 1", d.Value("a"))
+	}
+
+	// The error of the final save is returned by every Stop.
+	fl = c.FlushEvery(filepath.Join(t.TempDir(), "missing", "counts"), JSON, -time.Second)
+	err1, err2 := fl.Stop(), fl.Stop()
+	if err1 == nil || err1 != err2 {
+		t.Errorf("Stop: got %v then %v, want the same error", err1, err2)
+	}
+}
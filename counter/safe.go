@@ -0,0 +1,116 @@
+package counter
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"golang-demo/clock"
+)
+
+// SafeCounter is safe to use concurrently.
+// The zero value is an empty counter ready to use.
+type SafeCounter struct {
+	v   map[string]int
+	mux sync.Locker // guards v, &own if nil
+	own sync.Mutex
+	clk clock.Clock // Inc's nap, clock.Real if nil
+}
+
+// NewSafeCounter returns an empty SafeCounter.
+func NewSafeCounter() *SafeCounter {
+	return &SafeCounter{v: make(map[string]int)}
+}
+
+// NewSafeCounterWithLocker returns an empty SafeCounter guarded by l instead of its own mutex,
+// e.g. a *syncx.Mutex to measure how long callers wait for the counter.
+func NewSafeCounterWithLocker(l sync.Locker) *SafeCounter {
+	return &SafeCounter{v: make(map[string]int), mux: l}
+}
+
+// NewSafeCounterWithClock returns an empty SafeCounter whose Inc naps on clk instead of the wall clock,
+// e.g. a clock.Fake so that a test decides when the lock is released.
+func NewSafeCounterWithClock(clk clock.Clock) *SafeCounter {
+	return &SafeCounter{v: make(map[string]int), clk: clk}
+}
+
+func (c *SafeCounter) lock() {
+	if c.mux == nil {
+		c.own.Lock()
+	} else {
+		c.mux.Lock()
+	}
+}
+
+func (c *SafeCounter) unlock() {
+	if c.mux == nil {
+		c.own.Unlock()
+	} else {
+		c.mux.Unlock()
+	}
+}
+
+// Inc increments the counter for the given key.
+// It holds the lock for a millisecond more, so that concurrent callers have to wait for it.
+func (c *SafeCounter) Inc(key string) {
+	c.lock()
+	// Lock so only one goroutine at a time can access the map c.v.
+	if c.v == nil {
+		c.v = make(map[string]int)
+	}
+	c.v[key]++
+	clock.Or(c.clk).Sleep(time.Millisecond)
+	c.unlock()
+}
+
+// Add adds delta to the counter for the given key.
+func (c *SafeCounter) Add(key string, delta int) {
+	// Lock so only one goroutine at a time can access the map c.v.
+	c.lock()
+	defer c.unlock()
+	if c.v == nil {
+		c.v = make(map[string]int)
+	}
+	c.v[key] += delta
+}
+
+// Value returns the current value of the counter for the given key.
+func (c *SafeCounter) Value(key string) int {
+	c.lock()
+	defer c.unlock()
+	return c.v[key]
+}
+
+// Keys returns the counted keys in sorted order.
+func (c *SafeCounter) Keys() []string {
+	c.lock()
+	defer c.unlock()
+	keys := make([]string, 0, len(c.v))
+	for k := range c.v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Snapshot returns a copy of all the counters.
+func (c *SafeCounter) Snapshot() map[string]int {
+	c.lock()
+	defer c.unlock()
+	m := make(map[string]int, len(c.v))
+	for k, v := range c.v {
+		m[k] = v
+	}
+	return m
+}
+
+// Restore replaces all the counters with the values of m.
+func (c *SafeCounter) Restore(m map[string]int) {
+	v := make(map[string]int, len(m))
+	for k, n := range m {
+		v[k] = n
+	}
+	c.lock()
+	c.v = v
+	c.unlock()
+}
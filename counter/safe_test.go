@@ -0,0 +1,46 @@
+package counter
+
+import (
+	"testing"
+	"time"
+
+	"golang-demo/clock"
+)
+
+func TestSafeCounterIncClock(t *testing.T) {
+	f := clock.NewFake(t0)
+	c := NewSafeCounterWithClock(f)
+	done := make(chan struct{})
+	go func() {
+		c.Inc("a")
+		close(done)
+	}()
+	// Inc naps with the lock held: Value waits for the fake millisecond to pass.
+	f.BlockUntil(1)
+	read := make(chan int)
+	go func() { read <- c.Value("a") }()
+	select {
+	case <-done:
+		t.Fatal("Inc returned before Advance")
+	case n := <-read:
+		t.Fatalf("Value read %d while Inc held the lock", n)
+	case <-time.After(10 * time.Millisecond):
+	}
+	f.Advance(time.Millisecond)
+	<-done
+	if n := <-read; n != 1 {
+		t.Errorf("Value: got %d, want 1", n)
+	}
+}
+
+func TestSafeCounterZero(t *testing.T) {
+	var c SafeCounter
+	c.Inc("b")
+	c.Add("a", 2)
+	if got := c.Keys(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Keys: got %v, want [a b]", got)
+	}
+	if n := c.Value("a"); n != 2 {
+		t.Errorf("Value: got %d, want 2", n)
+	}
+}
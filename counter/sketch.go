@@ -0,0 +1,138 @@
+package counter
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// CountMin is a Count-Min Sketch: an approximate SafeCounter whose memory does not grow with the number of keys.
+// Value never under-estimates a count, and over-estimates it by at most epsilon*Total() with probability 1-delta.
+type CountMin struct {
+	width int
+	depth int
+	table [][]int
+	total int
+	mux   sync.Mutex
+}
+
+// ErrIncompatible is returned when merging sketches of different sizes.
+var ErrIncompatible = errors.New("counter: incompatible sketches")
+
+// NewCountMin returns a sketch with error bound epsilon (relative to the total count)
+// holding with probability 1-delta, e.g. NewCountMin(0.001, 0.01).
+func NewCountMin(epsilon, delta float64) *CountMin {
+	if epsilon <= 0 || delta <= 0 || delta >= 1 {
+		panic("counter: epsilon must be positive and delta in (0, 1)")
+	}
+	width := int(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	return NewCountMinSize(width, depth)
+}
+
+// NewCountMinSize returns a sketch of depth rows of width counters each.
+func NewCountMinSize(width, depth int) *CountMin {
+	if width < 1 || depth < 1 {
+		panic("counter: sketch dimensions must be positive")
+	}
+	table := make([][]int, depth)
+	for i := range table {
+		table[i] = make([]int, width)
+	}
+	return &CountMin{width: width, depth: depth, table: table}
+}
+
+// Width and Depth return the dimensions of the sketch.
+func (s *CountMin) Width() int { return s.width }
+func (s *CountMin) Depth() int { return s.depth }
+
+// Epsilon returns the error bound of Value relative to Total.
+func (s *CountMin) Epsilon() float64 {
+	return math.E / float64(s.width)
+}
+
+// Delta returns the probability that an estimate exceeds the error bound.
+func (s *CountMin) Delta() float64 {
+	return math.Exp(-float64(s.depth))
+}
+
+// hashes returns the two halves of the key's hash, row i uses h1 + i*h2 (double hashing).
+func hashes(key string) (uint32, uint32) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	return uint32(sum), uint32(sum>>32) | 1
+}
+
+func (s *CountMin) column(h1, h2 uint32, row int) int {
+	return int((h1 + uint32(row)*h2) % uint32(s.width))
+}
+
+// Inc increments the counter for the given key.
+func (s *CountMin) Inc(key string) {
+	s.Add(key, 1)
+}
+
+// Add adds n to the counter for the given key. The sketch cannot count down, n must not be negative.
+func (s *CountMin) Add(key string, n int) {
+	if n < 0 {
+		panic("counter: negative count added to a sketch")
+	}
+	h1, h2 := hashes(key)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for row := range s.table {
+		s.table[row][s.column(h1, h2, row)] += n
+	}
+	s.total += n
+}
+
+// Value returns the estimated count for the given key.
+func (s *CountMin) Value(key string) int {
+	h1, h2 := hashes(key)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	min := math.MaxInt
+	for row := range s.table {
+		if v := s.table[row][s.column(h1, h2, row)]; v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// Total returns the sum of all the counts added.
+func (s *CountMin) Total() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.total
+}
+
+// Merge adds the counts of other into s, as if all its events had been counted by s.
+// Both sketches must have the same dimensions.
+func (s *CountMin) Merge(other *CountMin) error {
+	if s.width != other.width || s.depth != other.depth {
+		return ErrIncompatible
+	}
+	if s == other {
+		return errors.New("counter: cannot merge a sketch into itself")
+	}
+	other.mux.Lock()
+	table := make([][]int, other.depth)
+	for i, row := range other.table {
+		table[i] = append([]int(nil), row...)
+	}
+	total := other.total
+	other.mux.Unlock()
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for i, row := range table {
+		for j, v := range row {
+			s.table[i][j] += v
+		}
+	}
+	s.total += total
+	return nil
+}
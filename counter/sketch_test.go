@@ -0,0 +1,90 @@
+package counter
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// zipfStream returns n keys drawn from a Zipf distribution over "k0" to "k999", a few keys being very frequent,
+// and the exact count of each key.
+func zipfStream(seed int64, n int) ([]string, map[string]int) {
+	rng := rand.New(rand.NewSource(seed))
+	z := rand.NewZipf(rng, 1.2, 1, 999)
+	keys := make([]string, n)
+	counts := make(map[string]int)
+	for i := range keys {
+		keys[i] = "k" + strconv.FormatUint(z.Uint64(), 10)
+		counts[keys[i]]++
+	}
+	return keys, counts
+}
+
+func TestCountMinBounds(t *testing.T) {
+	s := NewCountMin(0.01, 0.01)
+	keys, counts := zipfStream(1, 100000)
+	for _, k := range keys {
+		s.Inc(k)
+	}
+	if s.Total() != len(keys) {
+		t.Fatalf("Total: got %d, want %d", s.Total(), len(keys))
+	}
+	bound := int(s.Epsilon() * float64(s.Total()))
+	over := 0
+	for k, n := range counts {
+		v := s.Value(k)
+		if v < n {
+			t.Errorf("%s: got %d, under the true count %d", k, v, n)
+		}
+		if v-n > bound {
+			over++
+		}
+	}
+	// Each estimate is within the bound with probability 1-delta.
+	if limit := int(3 * s.Delta() * float64(len(counts))); over > max(limit, 1) {
+		t.Errorf("%d estimates of %d over the bound %d", over, len(counts), bound)
+	}
+	// A key never added is over-estimated within the bound too, but never negative.
+	if v := s.Value("missing"); v < 0 {
+		t.Errorf("missing key: got %d", v)
+	}
+}
+
+func TestCountMinMerge(t *testing.T) {
+	keys1, counts1 := zipfStream(1, 20000)
+	keys2, counts2 := zipfStream(2, 20000)
+	s1, s2, all := NewCountMinSize(200, 4), NewCountMinSize(200, 4), NewCountMinSize(200, 4)
+	for _, k := range keys1 {
+		s1.Inc(k)
+		all.Inc(k)
+	}
+	for _, k := range keys2 {
+		s2.Add(k, 1)
+		all.Add(k, 1)
+	}
+	if err := s1.Merge(s2); err != nil {
+		t.Fatal(err)
+	}
+	// Merging is exact: the same as counting both streams in one sketch.
+	for k := range counts1 {
+		if s1.Value(k) != all.Value(k) {
+			t.Errorf("%s: got %d, want %d", k, s1.Value(k), all.Value(k))
+		}
+		if s1.Value(k) < counts1[k]+counts2[k] {
+			t.Errorf("%s: got %d, under the true count %d", k, s1.Value(k), counts1[k]+counts2[k])
+		}
+	}
+	if s1.Total() != all.Total() {
+		t.Errorf("Total: got %d, want %d", s1.Total(), all.Total())
+	}
+
+	if err := s1.Merge(NewCountMinSize(100, 4)); err != ErrIncompatible {
+		t.Errorf("other width: got %v, want ErrIncompatible", err)
+	}
+	if err := s1.Merge(NewCountMinSize(200, 5)); err != ErrIncompatible {
+		t.Errorf("other depth: got %v, want ErrIncompatible", err)
+	}
+	if err := s1.Merge(s1); err == nil {
+		t.Error("merge into itself: got no error")
+	}
+}
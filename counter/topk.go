@@ -0,0 +1,193 @@
+package counter
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// TopK tracks the k most frequent keys with the Space-Saving algorithm, in memory proportional to k.
+// Any key counted more than Total()/k times is guaranteed to be tracked,
+// and the count of a tracked key is over-estimated by at most its Err.
+type TopK struct {
+	k       int
+	entries map[string]*Entry
+	heap    entryHeap // min-heap on Count, the first entry is the one to evict
+	total   int
+	mux     sync.Mutex
+}
+
+// Entry is a tracked key with its estimated count.
+// The true count is between Count-Err and Count.
+type Entry struct {
+	Key   string
+	Count int
+	Err   int
+	index int
+}
+
+// NewTopK returns a tracker of the k most frequent keys.
+func NewTopK(k int) *TopK {
+	if k < 1 {
+		panic("counter: TopK needs k >= 1")
+	}
+	return &TopK{k: k, entries: make(map[string]*Entry, k)}
+}
+
+// Inc increments the counter for the given key.
+func (t *TopK) Inc(key string) {
+	t.Add(key, 1)
+}
+
+// Add adds n (not negative) to the counter for the given key.
+func (t *TopK) Add(key string, n int) {
+	if n < 0 {
+		panic("counter: negative count added to TopK")
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.total += n
+	t.add(key, n, 0)
+}
+
+func (t *TopK) add(key string, n, err int) {
+	if e, ok := t.entries[key]; ok {
+		e.Count += n
+		e.Err += err
+		heap.Fix(&t.heap, e.index)
+		return
+	}
+	if len(t.heap) < t.k {
+		e := &Entry{Key: key, Count: n, Err: err}
+		t.entries[key] = e
+		heap.Push(&t.heap, e)
+		return
+	}
+	// Full: the new key takes over the smallest entry, inheriting its count as error.
+	e := t.heap[0]
+	delete(t.entries, e.Key)
+	e.Key = key
+	e.Err = e.Count + err
+	e.Count += n
+	t.entries[key] = e
+	heap.Fix(&t.heap, 0)
+}
+
+// Value returns the estimated count for the given key.
+// For a key that is not tracked, that is the upper bound of its count: the smallest tracked count once full, 0 before.
+func (t *TopK) Value(key string) int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if e, ok := t.entries[key]; ok {
+		return e.Count
+	}
+	return t.floor()
+}
+
+// floor is the count any untracked key may have.
+func (t *TopK) floor() int {
+	if len(t.heap) < t.k {
+		return 0
+	}
+	return t.heap[0].Count
+}
+
+// Total returns the sum of all the counts added.
+func (t *TopK) Total() int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.total
+}
+
+// snapshot returns a copy of the tracked entries in no particular order, t.mux must be held.
+func (t *TopK) snapshot() []Entry {
+	entries := make([]Entry, len(t.heap))
+	for i, e := range t.heap {
+		entries[i] = Entry{Key: e.Key, Count: e.Count, Err: e.Err}
+	}
+	return entries
+}
+
+// Top returns the tracked entries, most frequent first.
+func (t *TopK) Top() []Entry {
+	t.mux.Lock()
+	entries := t.snapshot()
+	t.mux.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// Merge adds the counts tracked by other into t, as if t had seen the events of both.
+// A key missing from one side may have been counted up to that side's smallest count, which is added as error.
+func (t *TopK) Merge(other *TopK) error {
+	if t == other {
+		return errors.New("counter: cannot merge a TopK into itself")
+	}
+	// One consistent view of other: its entries, floor and total must come from the same moment.
+	other.mux.Lock()
+	theirs, theirFloor, theirTotal := other.snapshot(), other.floor(), other.total
+	other.mux.Unlock()
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	ourFloor := t.floor()
+	seen := make(map[string]bool, len(theirs))
+	merged := make([]Entry, 0, len(t.heap)+len(theirs))
+	for _, e := range theirs {
+		seen[e.Key] = true
+		if ours, ok := t.entries[e.Key]; ok {
+			merged = append(merged, Entry{Key: e.Key, Count: ours.Count + e.Count, Err: ours.Err + e.Err})
+		} else {
+			merged = append(merged, Entry{Key: e.Key, Count: ourFloor + e.Count, Err: ourFloor + e.Err})
+		}
+	}
+	for _, ours := range t.heap {
+		if !seen[ours.Key] {
+			merged = append(merged, Entry{Key: ours.Key, Count: ours.Count + theirFloor, Err: ours.Err + theirFloor})
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Count > merged[j].Count })
+	if len(merged) > t.k {
+		merged = merged[:t.k]
+	}
+
+	t.entries = make(map[string]*Entry, t.k)
+	t.heap = t.heap[:0]
+	for i := range merged {
+		e := &merged[i]
+		t.entries[e.Key] = e
+		heap.Push(&t.heap, e)
+	}
+	t.total += theirTotal
+	return nil
+}
+
+// entryHeap implements heap.Interface, ordered by Count.
+type entryHeap []*Entry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return h[i].Count < h[j].Count }
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *entryHeap) Push(x interface{}) {
+	e := x.(*Entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,113 @@
+package counter
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// checkTopK checks the guarantees of tk after it counted the events of counts:
+// the true count of a tracked key is between Count-Err and Count, and every key counted more than Total/k is tracked.
+func checkTopK(t *testing.T, name string, tk *TopK, k int, counts map[string]int) {
+	t.Helper()
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if tk.Total() != total {
+		t.Errorf("%s: Total got %d, want %d", name, tk.Total(), total)
+	}
+	top := tk.Top()
+	if len(top) > k {
+		t.Errorf("%s: %d entries tracked, k is %d", name, len(top), k)
+	}
+	tracked := make(map[string]bool, len(top))
+	for i, e := range top {
+		tracked[e.Key] = true
+		if n := counts[e.Key]; n < e.Count-e.Err || n > e.Count {
+			t.Errorf("%s: %s counted %d, estimated %d with error %d", name, e.Key, n, e.Count, e.Err)
+		}
+		if i > 0 && top[i-1].Count < e.Count {
+			t.Errorf("%s: Top not sorted: %v", name, top)
+		}
+	}
+	for key, n := range counts {
+		if n > total/k && !tracked[key] {
+			t.Errorf("%s: %s counted %d > %d/%d but not tracked", name, key, n, total, k)
+		}
+		if v := tk.Value(key); v < n {
+			t.Errorf("%s: Value(%s) got %d, under the true count %d", name, key, v, n)
+		}
+	}
+}
+
+func TestTopK(t *testing.T) {
+	const k = 20
+	keys, counts := zipfStream(1, 50000)
+	tk := NewTopK(k)
+	for _, key := range keys {
+		tk.Inc(key)
+	}
+	checkTopK(t, "one stream", tk, k, counts)
+	// With a skewed distribution, the most frequent keys come first.
+	if top := tk.Top(); top[0].Key != "k0" || top[1].Key != "k1" {
+		t.Errorf("got %v first, want k0 then k1", top[:2])
+	}
+}
+
+func TestTopKMerge(t *testing.T) {
+	const k = 20
+	// Two streams with different frequent keys: "k0", "k1"... in the first, "k999", "k998"... in the second.
+	keys1, counts1 := zipfStream(1, 30000)
+	keys2, counts2 := zipfStream(2, 20000)
+	t1, t2 := NewTopK(k), NewTopK(k)
+	for _, key := range keys1 {
+		t1.Inc(key)
+	}
+	flipped := make(map[string]int, len(counts2))
+	for _, key := range keys2 {
+		key = flip(key)
+		t2.Add(key, 1)
+		flipped[key]++
+	}
+	all := make(map[string]int)
+	for _, counts := range []map[string]int{counts1, flipped} {
+		for key, n := range counts {
+			all[key] += n
+		}
+	}
+	if err := t1.Merge(t2); err != nil {
+		t.Fatal(err)
+	}
+	checkTopK(t, "merged", t1, k, all)
+	// Merging doesn't change other.
+	checkTopK(t, "merged from", t2, k, flipped)
+
+	if err := t1.Merge(t1); err == nil {
+		t.Error("merge into itself: got no error")
+	}
+}
+
+// flip maps "k<n>" to "k<999-n>".
+func flip(key string) string {
+	n, _ := strconv.Atoi(key[1:])
+	return "k" + strconv.Itoa(999-n)
+}
+
+func TestTopKSmall(t *testing.T) {
+	tk := NewTopK(2)
+	tk.Add("a", 5)
+	tk.Add("b", 3)
+	if v := tk.Value("c"); v != 3 {
+		t.Errorf("untracked key when full: got %d, want the floor 3", v)
+	}
+	// c takes over b, the smallest entry, and inherits its count as error.
+	tk.Inc("c")
+	want := []Entry{{Key: "a", Count: 5}, {Key: "c", Count: 4, Err: 3}}
+	if got := tk.Top(); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if v := NewTopK(2).Value("a"); v != 0 {
+		t.Errorf("untracked key before full: got %d, want 0", v)
+	}
+}
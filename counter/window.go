@@ -0,0 +1,183 @@
+// Package counter provides counters keyed by string that are safe to use concurrently.
+package counter
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"golang-demo/clock"
+)
+
+// Window is a sliding-window counter: like SafeCounter it counts events per key,
+// but it only remembers the events of the last `size` duration.
+// Events are grouped into time buckets of `resolution` width, so a query for "the last d"
+// is precise to one bucket.
+type Window struct {
+	size       time.Duration
+	resolution time.Duration
+	clock      clock.Clock
+	v          map[string]*buckets
+	mux        sync.Mutex
+}
+
+// buckets is a ring of counters for one key. Slot i holds the events of bucket epoch[i],
+// where a bucket's epoch is the time divided by the resolution.
+type buckets struct {
+	counts []int
+	epoch  []int64
+	last   int64 // epoch of the most recent event
+}
+
+// NewWindow returns a Window remembering `size` worth of events in buckets of `resolution` width.
+// The size is rounded up to a whole number of buckets. A nil clock means the real clock.
+func NewWindow(size, resolution time.Duration, c clock.Clock) *Window {
+	if resolution <= 0 {
+		panic("counter: non-positive window resolution")
+	}
+	if size < resolution {
+		size = resolution
+	}
+	n := (size + resolution - 1) / resolution
+	return &Window{
+		size:       n * resolution,
+		resolution: resolution,
+		clock:      clock.Or(c),
+		v:          make(map[string]*buckets),
+	}
+}
+
+// Size returns the length of time the counter remembers.
+func (w *Window) Size() time.Duration {
+	return w.size
+}
+
+func (w *Window) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(w.resolution)
+}
+
+// Inc increments the counter for the given key.
+func (w *Window) Inc(key string) {
+	w.Add(key, 1)
+}
+
+// Add adds delta events to the counter for the given key.
+func (w *Window) Add(key string, delta int) {
+	now := w.epoch(w.clock.Now())
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	b, ok := w.v[key]
+	if !ok {
+		n := int(w.size / w.resolution)
+		b = &buckets{counts: make([]int, n), epoch: make([]int64, n)}
+		w.v[key] = b
+	}
+	n := int64(len(b.counts))
+	i := (now%n + n) % n
+	if b.epoch[i] != now {
+		// The slot still holds an expired bucket, reuse it.
+		b.epoch[i] = now
+		b.counts[i] = 0
+	}
+	b.counts[i] += delta
+	if now > b.last {
+		b.last = now
+	}
+}
+
+// Value returns the number of events for the given key over the whole window.
+func (w *Window) Value(key string) int {
+	return w.Count(key, w.size)
+}
+
+// Count returns the number of events for the given key in the last d (at most the window size).
+// d is rounded up to whole buckets, the current (partial) bucket included.
+func (w *Window) Count(key string, d time.Duration) int {
+	now := w.epoch(w.clock.Now())
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	b, ok := w.v[key]
+	if !ok {
+		return 0
+	}
+	return b.sum(now, w.span(d))
+}
+
+// Rate returns the average number of events per second for the given key in the last d.
+func (w *Window) Rate(key string, d time.Duration) float64 {
+	n := w.span(d)
+	return float64(w.Count(key, d)) / (time.Duration(n) * w.resolution).Seconds()
+}
+
+// span returns how many buckets cover the duration d.
+func (w *Window) span(d time.Duration) int64 {
+	if d > w.size {
+		d = w.size
+	}
+	n := int64((d + w.resolution - 1) / w.resolution)
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// sum adds up the buckets whose epoch is in (now-n, now].
+func (b *buckets) sum(now, n int64) int {
+	total := 0
+	for i, e := range b.epoch {
+		if e <= now && e > now-n {
+			total += b.counts[i]
+		}
+	}
+	return total
+}
+
+// Snapshot returns the number of events in the window for every key that has some.
+func (w *Window) Snapshot() map[string]int {
+	now := w.epoch(w.clock.Now())
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	m := make(map[string]int, len(w.v))
+	for k, b := range w.v {
+		if b.live(now) {
+			m[k] = b.sum(now, int64(len(b.counts)))
+		}
+	}
+	return m
+}
+
+// Keys returns the keys that have events in the window, in sorted order.
+func (w *Window) Keys() []string {
+	now := w.epoch(w.clock.Now())
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	keys := make([]string, 0, len(w.v))
+	for k, b := range w.v {
+		if b.live(now) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (b *buckets) live(now int64) bool {
+	return b.last > now-int64(len(b.counts))
+}
+
+// Evict drops the keys whose buckets have all expired and returns how many were dropped.
+// Expired buckets of live keys are recycled on write, so calling Evict periodically keeps memory
+// proportional to the number of recently active keys.
+func (w *Window) Evict() int {
+	now := w.epoch(w.clock.Now())
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	n := 0
+	for k, b := range w.v {
+		if !b.live(now) {
+			delete(w.v, k)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,173 @@
+package counter
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"golang-demo/clock"
+)
+
+var t0 = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// event adds n to key at t0 plus at.
+type event struct {
+	at  time.Duration
+	key string
+	n   int
+}
+
+// windowAt returns a 10s window in buckets of 1s holding the events, its fake clock set to t0 plus now.
+func windowAt(events []event, now time.Duration) *Window {
+	f := clock.NewFake(t0)
+	w := NewWindow(10*time.Second, time.Second, f)
+	for _, e := range events {
+		f.Set(t0.Add(e.at))
+		w.Add(e.key, e.n)
+	}
+	f.Set(t0.Add(now))
+	return w
+}
+
+// Events in the buckets 0, 3 and 9.
+var windowEvents = []event{
+	{0, "a", 1},
+	{3500 * time.Millisecond, "a", 2},
+	{9900 * time.Millisecond, "a", 4},
+}
+
+func TestWindowCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		now, d  time.Duration
+		want    int
+		wantAll int // Value, the count over the whole window
+	}{
+		{"whole window", 9900 * time.Millisecond, 10 * time.Second, 7, 7},
+		{"current bucket", 9900 * time.Millisecond, time.Second, 4, 7},
+		{"below resolution", 9900 * time.Millisecond, 500 * time.Millisecond, 4, 7},
+		{"zero", 9900 * time.Millisecond, 0, 4, 7},
+		{"negative", 9900 * time.Millisecond, -time.Second, 4, 7},
+		{"bucket edge in", 9900 * time.Millisecond, 7 * time.Second, 6, 7},
+		{"bucket edge out", 9900 * time.Millisecond, 6 * time.Second, 4, 7},
+		{"above window", 9900 * time.Millisecond, time.Hour, 7, 7},
+		// Bucket 0 expires when bucket 10 starts.
+		{"before first expiry", 9999 * time.Millisecond, time.Hour, 7, 7},
+		{"first expiry", 10 * time.Second, time.Hour, 6, 6},
+		{"second expiry", 13 * time.Second, time.Hour, 4, 4},
+		{"last bucket left", 18999 * time.Millisecond, 10 * time.Second, 4, 4},
+		{"all expired", 19 * time.Second, 10 * time.Second, 0, 0},
+	}
+	for _, tt := range tests {
+		w := windowAt(windowEvents, tt.now)
+		if got := w.Count("a", tt.d); got != tt.want {
+			t.Errorf("%s: Count(%v) at %v: got %d, want %d", tt.name, tt.d, tt.now, got, tt.want)
+		}
+		if got := w.Value("a"); got != tt.wantAll {
+			t.Errorf("%s: Value at %v: got %d, want %d", tt.name, tt.now, got, tt.wantAll)
+		}
+		if got := w.Count("missing", tt.d); got != 0 {
+			t.Errorf("%s: Count of a missing key: got %d", tt.name, got)
+		}
+	}
+}
+
+func TestWindowRate(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want float64
+	}{
+		{10 * time.Second, 0.7},
+		{time.Hour, 0.7}, // over the window at most
+		{2 * time.Second, 2},
+		{time.Second, 4},
+		{500 * time.Millisecond, 4}, // one whole bucket
+	}
+	w := windowAt(windowEvents, 9900*time.Millisecond)
+	for _, tt := range tests {
+		if got := w.Rate("a", tt.d); got != tt.want {
+			t.Errorf("Rate(%v): got %v, want %v", tt.d, got, tt.want)
+		}
+	}
+	if got := w.Rate("missing", time.Second); got != 0 {
+		t.Errorf("Rate of a missing key: got %v", got)
+	}
+}
+
+func TestWindowEvict(t *testing.T) {
+	events := []event{{0, "a", 1}, {5 * time.Second, "b", 1}, {5 * time.Second, "c", 0}}
+	tests := []struct {
+		now       time.Duration
+		keys      []string
+		snapshot  map[string]int
+		evicted   int
+		remaining int // keys left in the map after Evict
+	}{
+		{9 * time.Second, []string{"a", "b", "c"}, map[string]int{"a": 1, "b": 1, "c": 0}, 0, 3},
+		{10 * time.Second, []string{"b", "c"}, map[string]int{"b": 1, "c": 0}, 1, 2},
+		{15 * time.Second, nil, map[string]int{}, 3, 0},
+	}
+	for _, tt := range tests {
+		w := windowAt(events, tt.now)
+		if keys := w.Keys(); !slices.Equal(keys, tt.keys) {
+			t.Errorf("at %v: Keys got %v, want %v", tt.now, keys, tt.keys)
+		}
+		if got := w.Snapshot(); !maps.Equal(got, tt.snapshot) {
+			t.Errorf("at %v: Snapshot got %v, want %v", tt.now, got, tt.snapshot)
+		}
+		if n := w.Evict(); n != tt.evicted {
+			t.Errorf("at %v: Evict got %d, want %d", tt.now, n, tt.evicted)
+		}
+		if len(w.v) != tt.remaining {
+			t.Errorf("at %v: %d keys left, want %d", tt.now, len(w.v), tt.remaining)
+		}
+		if n := w.Evict(); n != 0 {
+			t.Errorf("at %v: second Evict got %d", tt.now, n)
+		}
+	}
+}
+
+func TestWindowSlotReuse(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []event
+		now    time.Duration
+		want   int
+	}{
+		// Bucket 2 and bucket 12 share a slot: the old count must not leak into the new one.
+		{"one window later", []event{{2 * time.Second, "a", 5}, {12 * time.Second, "a", 1}}, 12 * time.Second, 1},
+		{"several windows later", []event{{2 * time.Second, "a", 5}, {32 * time.Second, "a", 1}}, 32 * time.Second, 1},
+		{"gap then others", []event{{2 * time.Second, "a", 5}, {35 * time.Second, "a", 1}, {42 * time.Second, "a", 2}}, 42 * time.Second, 3},
+		{"nothing new", []event{{2 * time.Second, "a", 5}}, 32 * time.Second, 0},
+		// Several events in the same bucket add up.
+		{"same bucket", []event{{2 * time.Second, "a", 5}, {2900 * time.Millisecond, "a", 1}}, 3 * time.Second, 6},
+	}
+	for _, tt := range tests {
+		w := windowAt(tt.events, tt.now)
+		if got := w.Value("a"); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewWindow(t *testing.T) {
+	tests := []struct {
+		size, resolution, want time.Duration
+	}{
+		{10 * time.Second, time.Second, 10 * time.Second},
+		{2500 * time.Millisecond, time.Second, 3 * time.Second},
+		{time.Millisecond, time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		if got := NewWindow(tt.size, tt.resolution, nil).Size(); got != tt.want {
+			t.Errorf("NewWindow(%v, %v).Size(): got %v, want %v", tt.size, tt.resolution, got, tt.want)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("NewWindow with a zero resolution didn't panic")
+		}
+	}()
+	NewWindow(time.Second, 0, nil)
+}
@@ -0,0 +1,148 @@
+// Package generator provides lazy sequences of values that can be consumed either pushed through a channel,
+// like `fib` and `fibSelect` do, or pulled one at a time with Next, and stopped at any time without leaking goroutines.
+package generator
+
+import (
+	"context"
+	"iter"
+)
+
+// Generator is a lazy, possibly infinite, sequence of values.
+// Each consumer (Chan, Iter, All) runs the sequence from its start.
+type Generator[T any] struct {
+	// seq runs the sequence until yield returns false or ctx is done.
+	seq func(ctx context.Context, yield func(T) bool)
+}
+
+// New returns a generator of the values passed to yield by produce.
+// produce must return as soon as yield returns false, that is when the consumer has stopped
+// or, for Chan, when its context is done: a producer sees the cancel on the next value it yields,
+// even if every value is dropped further down the chain, e.g. by Filter.
+func New[T any](produce func(yield func(T) bool)) *Generator[T] {
+	return &Generator[T]{seq: func(ctx context.Context, yield func(T) bool) {
+		produce(func(v T) bool {
+			return ctx.Err() == nil && yield(v)
+		})
+	}}
+}
+
+// derive returns a generator built on others, which see ctx through their own producers.
+func derive[T any](seq func(ctx context.Context, yield func(T) bool)) *Generator[T] {
+	return &Generator[T]{seq: seq}
+}
+
+// All returns the generator as an iterator, to be used in a for-range loop.
+func (g *Generator[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		g.seq(context.Background(), yield)
+	}
+}
+
+// Chan pushes the values to the returned channel from a new goroutine, until the values are exhausted
+// or ctx is done, then closes the channel. Cancelling ctx is how a consumer that stops early
+// lets the goroutine exit (no separate `quit` channel as in fibSelect).
+func (g *Generator[T]) Chan(ctx context.Context) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		g.seq(ctx, func(v T) bool {
+			select {
+			case ch <- v:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// Iterator pulls the values of a generator one at a time.
+type Iterator[T any] struct {
+	next func() (T, bool)
+	stop func()
+}
+
+// Iter returns an iterator over the values. Call Stop when done with it, unless Next has returned false:
+// iter.Pull runs the sequence in a coroutine, which only exits once the values are exhausted or Stop is called.
+func (g *Generator[T]) Iter() *Iterator[T] {
+	next, stop := iter.Pull(g.All())
+	return &Iterator[T]{next, stop}
+}
+
+// Next returns the next value, or false once the values are exhausted or the iterator stopped.
+func (it *Iterator[T]) Next() (T, bool) {
+	return it.next()
+}
+
+// Stop releases the iterator, Next returns false afterwards.
+func (it *Iterator[T]) Stop() {
+	it.stop()
+}
+
+// Slice returns all the values in a slice, the generator must be finite.
+func (g *Generator[T]) Slice() []T {
+	var s []T
+	for v := range g.All() {
+		s = append(s, v)
+	}
+	return s
+}
+
+// Take returns a generator of the first n values.
+func (g *Generator[T]) Take(n int) *Generator[T] {
+	return derive(func(ctx context.Context, yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		g.seq(ctx, func(v T) bool {
+			if !yield(v) {
+				return false
+			}
+			i++
+			return i < n
+		})
+	})
+}
+
+// Skip returns a generator of the values after the first n.
+func (g *Generator[T]) Skip(n int) *Generator[T] {
+	return derive(func(ctx context.Context, yield func(T) bool) {
+		i := 0
+		g.seq(ctx, func(v T) bool {
+			if i < n {
+				i++
+				return true
+			}
+			return yield(v)
+		})
+	})
+}
+
+// TakeWhile returns a generator of the values up to the first one for which keep returns false.
+func (g *Generator[T]) TakeWhile(keep func(T) bool) *Generator[T] {
+	return derive(func(ctx context.Context, yield func(T) bool) {
+		g.seq(ctx, func(v T) bool {
+			return keep(v) && yield(v)
+		})
+	})
+}
+
+// Filter returns a generator of the values for which keep returns true.
+func (g *Generator[T]) Filter(keep func(T) bool) *Generator[T] {
+	return derive(func(ctx context.Context, yield func(T) bool) {
+		g.seq(ctx, func(v T) bool {
+			return !keep(v) || yield(v)
+		})
+	})
+}
+
+// Map returns a generator of fn applied to the values of g.
+func Map[T, U any](g *Generator[T], fn func(T) U) *Generator[U] {
+	return derive(func(ctx context.Context, yield func(U) bool) {
+		g.seq(ctx, func(v T) bool {
+			return yield(fn(v))
+		})
+	})
+}
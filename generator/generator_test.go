@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"golang-demo/leaktest"
+)
+
+func TestChanCancel(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := Fibonacci().Chan(ctx)
+	var got []int
+	for v := range ch {
+		got = append(got, v)
+		if len(got) == 5 {
+			break
+		}
+	}
+	// The producer is blocked on its next send: cancelling lets it return and close the channel.
+	cancel()
+	for range ch {
+	}
+	if want := []int{0, 1, 1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestChanExhausted(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	var got []int
+	for v := range Range(0, 5, 1).Chan(context.Background()) {
+		got = append(got, v)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// With a context already done, the infinite producer gives up on one of its first sends
+	// (select picks at random when both cases are ready) and the channel is closed: the loop ends.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range Count(0).Chan(ctx) {
+	}
+}
+
+func TestChanCancelNoValues(t *testing.T) {
+	// A generator that never yields: the producer runs on without ever trying to send,
+	// it must still notice the cancel through the values Filter drops.
+	defer leaktest.Check(t, leaktest.Options{})()
+	for _, g := range []*Generator[int]{
+		Count(0).Filter(func(int) bool { return false }),
+		Map(Count(0).Skip(math.MaxInt), func(i int) int { return i }),
+		Count(0).TakeWhile(func(int) bool { return true }).Filter(func(int) bool { return false }),
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := g.Chan(ctx)
+		time.Sleep(time.Millisecond)
+		cancel()
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Error("got a value")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("channel not closed after cancel")
+		}
+	}
+}
+
+func TestIterStop(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	it := Count(10).Iter()
+	for _, want := range []int{10, 11, 12} {
+		if v, ok := it.Next(); !ok || v != want {
+			t.Fatalf("got %d, %v, want %d, true", v, ok, want)
+		}
+	}
+	it.Stop()
+	if _, ok := it.Next(); ok {
+		t.Error("Next after Stop: got true")
+	}
+	it.Stop() // stopping twice is fine
+}
+
+func TestIterExhausted(t *testing.T) {
+	// No Stop needed once Next has returned false.
+	defer leaktest.Check(t, leaktest.Options{})()
+	it := Range(0, 2, 1).Iter()
+	var got []int
+	for v, ok := it.Next(); ok; v, ok = it.Next() {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{0, 1}) {
+		t.Errorf("got %v, want [0 1]", got)
+	}
+}
+
+func TestTakeSkip(t *testing.T) {
+	tests := []struct {
+		name string
+		g    *Generator[int]
+		want []int
+	}{
+		{"Take(-1)", Count(0).Take(-1), nil},
+		{"Take(0)", Count(0).Take(0), nil},
+		{"Take(1)", Count(0).Take(1), []int{0}},
+		{"Take(3)", Count(0).Take(3), []int{0, 1, 2}},
+		{"Take beyond the end", Range(0, 3, 1).Take(10), []int{0, 1, 2}},
+		{"Skip(-1)", Range(0, 3, 1).Skip(-1), []int{0, 1, 2}},
+		{"Skip(0)", Range(0, 3, 1).Skip(0), []int{0, 1, 2}},
+		{"Skip(2)", Range(0, 3, 1).Skip(2), []int{2}},
+		{"Skip(3)", Range(0, 3, 1).Skip(3), nil},
+		{"Skip beyond the end", Range(0, 3, 1).Skip(10), nil},
+		{"Skip then Take", Count(0).Skip(5).Take(2), []int{5, 6}},
+		{"TakeWhile none", Count(0).TakeWhile(func(int) bool { return false }), nil},
+		{"TakeWhile some", Count(0).TakeWhile(func(i int) bool { return i < 3 }), []int{0, 1, 2}},
+		{"TakeWhile all", Range(0, 3, 1).TakeWhile(func(int) bool { return true }), []int{0, 1, 2}},
+		{"TakeWhile stops at the first false", Range(0, 6, 1).TakeWhile(func(i int) bool { return i != 2 }), []int{0, 1}},
+		{"Filter", Count(0).Filter(func(i int) bool { return i%3 == 0 }).Take(3), []int{0, 3, 6}},
+		{"Map", Map(Range(1, 4, 1), func(i int) int { return i * i }), []int{1, 4, 9}},
+	}
+	for _, tt := range tests {
+		if got := tt.g.Slice(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		// Every consumer runs the sequence from its start.
+		if got := slices.Collect(tt.g.All()); !slices.Equal(got, tt.want) {
+			t.Errorf("%s, again: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
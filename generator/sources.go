@@ -0,0 +1,57 @@
+package generator
+
+// Fibonacci returns the infinite generator of the Fibonacci numbers 0, 1, 1, 2, 3, 5...
+// (they overflow int after the 92nd).
+func Fibonacci() *Generator[int] {
+	return New(func(yield func(int) bool) {
+		x, y := 0, 1
+		for yield(x) {
+			x, y = y, x+y
+		}
+	})
+}
+
+// Primes returns the infinite generator of the prime numbers, computed with an incremental sieve:
+// each composite number found is mapped to the primes dividing it, which are moved to their next multiple.
+func Primes() *Generator[int] {
+	return New(func(yield func(int) bool) {
+		composites := map[int][]int{}
+		for n := 2; ; n++ {
+			factors, ok := composites[n]
+			if !ok {
+				// n is prime, its first multiple worth crossing out is n*n.
+				if !yield(n) {
+					return
+				}
+				composites[n*n] = []int{n}
+				continue
+			}
+			for _, p := range factors {
+				composites[n+p] = append(composites[n+p], p)
+			}
+			delete(composites, n)
+		}
+	})
+}
+
+// Range returns the generator of start, start+step, ... up to end excluded (down to end if step is negative).
+func Range(start, end, step int) *Generator[int] {
+	if step == 0 {
+		panic("generator: Range step must not be 0")
+	}
+	return New(func(yield func(int) bool) {
+		for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+			if !yield(i) {
+				return
+			}
+		}
+	})
+}
+
+// Count returns the infinite generator of start, start+1, start+2...
+func Count(start int) *Generator[int] {
+	return New(func(yield func(int) bool) {
+		for i := start; yield(i); i++ {
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFibonacci(t *testing.T) {
+	want := []int{0, 1, 1, 2, 3, 5, 8, 13, 21, 34, 55, 89, 144, 233, 377, 610}
+	if got := Fibonacci().Take(len(want)).Slice(); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// The largest one that fits in an int64.
+	if got := Fibonacci().Skip(92).Take(1).Slice(); !slices.Equal(got, []int{7540113804746346429}) {
+		t.Errorf("F(92): got %v", got)
+	}
+}
+
+func TestPrimes(t *testing.T) {
+	want := []int{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67, 71, 73, 79, 83, 89, 97}
+	if got := Primes().Take(len(want)).Slice(); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := Primes().Skip(999).Take(1).Slice(); !slices.Equal(got, []int{7919}) {
+		t.Errorf("1000th prime: got %v, want 7919", got)
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		start, end, step int
+		want             []int
+	}{
+		{0, 5, 2, []int{0, 2, 4}},
+		{5, 0, -2, []int{5, 3, 1}},
+		{0, 0, 1, nil},
+		{0, 5, -1, nil},
+		{5, 0, 1, nil},
+	}
+	for _, tt := range tests {
+		if got := Range(tt.start, tt.end, tt.step).Slice(); !slices.Equal(got, tt.want) {
+			t.Errorf("Range(%d, %d, %d): got %v, want %v", tt.start, tt.end, tt.step, got, tt.want)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Range with a zero step didn't panic")
+		}
+	}()
+	Range(0, 1, 0)
+}
@@ -1,5 +1,5 @@
 module golang-demo
 
-go 1.12
+go 1.23
 
 require golang.org/x/tour v0.0.0-20190318020441-db40fe78fefc
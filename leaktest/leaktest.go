@@ -0,0 +1,167 @@
+// Package leaktest finds goroutines left running by a piece of code, such as `SameTree` walkers
+// blocked forever on a send nobody receives. In a test:
+//
+//	func TestSameTree(t *testing.T) {
+//		defer leaktest.Check(t, leaktest.Options{})()
+//		...
+//	}
+//
+// The test fails if goroutines started during the test are still running at the end, with their stacks.
+package leaktest
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Goroutine is a running goroutine, as found in the stack dump of runtime.Stack.
+type Goroutine struct {
+	ID    uint64
+	State string // e.g. "chan send", "select", "sleep"
+	Stack string // the whole entry of the dump, header included
+}
+
+// DefaultIgnore are the goroutines of the testing package and of the runtime that may come and go during a test.
+var DefaultIgnore = []string{
+	"testing.tRunner(",
+	"testing.(*T).Run(",
+	"testing.(*F).Fuzz(",
+	"testing.runFuzzing(",
+	"os/signal.signal_recv(",
+	"os/signal.loop(",
+	"runtime.ensureSigM(",
+}
+
+// Options tune the search for leaks.
+type Options struct {
+	// Timeout is how long goroutines have to exit before they count as leaked, one second if zero.
+	// Goroutines that have been told to stop, e.g. by cancelling a context, may need a moment to return.
+	Timeout time.Duration
+	// Ignore lists known background goroutines: a goroutine whose stack contains one of the strings,
+	// typically a function name such as "net/http.(*persistConn).readLoop", is never a leak.
+	// DefaultIgnore is always used in addition.
+	Ignore []string
+}
+
+// Snapshot returns the running goroutines, except the calling one.
+func Snapshot() []Goroutine {
+	self := currentID()
+	var gs []Goroutine
+	for _, g := range parse(stacks()) {
+		if g.ID != self {
+			gs = append(gs, g)
+		}
+	}
+	return gs
+}
+
+// stacks returns the stack dump of all the goroutines, growing the buffer until it fits.
+func stacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// parse splits a stack dump into goroutines. Each entry starts with a header like
+// "goroutine 18 [chan receive, 2 minutes]:" and entries are separated by blank lines.
+func parse(dump []byte) []Goroutine {
+	var gs []Goroutine
+	for _, entry := range bytes.Split(dump, []byte("\n\n")) {
+		entry = bytes.TrimSpace(entry)
+		header, _, _ := bytes.Cut(entry, []byte("\n"))
+		rest, ok := bytes.CutPrefix(header, []byte("goroutine "))
+		if !ok {
+			continue
+		}
+		idStr, state, _ := bytes.Cut(rest, []byte(" "))
+		id, err := strconv.ParseUint(string(idStr), 10, 64)
+		if err != nil {
+			continue
+		}
+		state = bytes.TrimSuffix(bytes.TrimPrefix(state, []byte("[")), []byte("]:"))
+		state, _, _ = bytes.Cut(state, []byte(",")) // drop the wait duration
+		gs = append(gs, Goroutine{ID: id, State: string(state), Stack: string(entry)})
+	}
+	return gs
+}
+
+func currentID() uint64 {
+	var buf [64]byte
+	gs := parse(buf[:runtime.Stack(buf[:], false)])
+	if len(gs) == 0 {
+		return 0
+	}
+	return gs[0].ID
+}
+
+func ignored(g Goroutine, ignore []string) bool {
+	for _, s := range ignore {
+		if strings.Contains(g.Stack, s) {
+			return true
+		}
+	}
+	for _, s := range DefaultIgnore {
+		if strings.Contains(g.Stack, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Leaked returns the goroutines running now that were not running in before, except the ignored ones.
+// It waits up to opts.Timeout for them to exit before reporting them.
+func Leaked(before []Goroutine, opts Options) []Goroutine {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	known := make(map[uint64]bool, len(before))
+	for _, g := range before {
+		known[g.ID] = true
+	}
+	deadline := time.Now().Add(opts.Timeout)
+	for wait := time.Millisecond; ; wait = min(2*wait, 100*time.Millisecond) {
+		var leaked []Goroutine
+		for _, g := range Snapshot() {
+			if !known[g.ID] && !ignored(g, opts.Ignore) {
+				leaked = append(leaked, g)
+			}
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(wait)
+	}
+}
+
+// Check takes a snapshot of the running goroutines and returns a function that fails t
+// if new goroutines are still running when it is called, typically deferred at the start of a test.
+func Check(t testing.TB, opts Options) func() {
+	before := Snapshot()
+	return func() {
+		t.Helper()
+		if leaked := Leaked(before, opts); len(leaked) > 0 {
+			t.Error(Report(leaked))
+		}
+	}
+}
+
+// Report describes leaked goroutines with their stacks.
+func Report(leaked []Goroutine) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "leaktest: %d leaked goroutine(s):", len(leaked))
+	for _, g := range leaked {
+		b.WriteString("\n\n")
+		b.WriteString(g.Stack)
+	}
+	return b.String()
+}
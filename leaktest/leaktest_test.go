@@ -0,0 +1,120 @@
+package leaktest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeT records the failures of Check instead of failing the test.
+type fakeT struct {
+	testing.TB // not set: calling a method that isn't overridden panics
+	errors     []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Error(args ...any) {
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// blockedForever blocks on a receive until done is closed, a leak as long as nobody closes it.
+func blockedForever(done <-chan struct{}) {
+	<-done
+}
+
+func TestCheckReportsLeak(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	ft := &fakeT{}
+	check := Check(ft, Options{Timeout: 50 * time.Millisecond})
+	go blockedForever(done)
+	check()
+
+	if len(ft.errors) != 1 {
+		t.Fatalf("got %d failures, want 1: %q", len(ft.errors), ft.errors)
+	}
+	report := ft.errors[0]
+	for _, want := range []string{"leaktest: 1 leaked goroutine(s)", "[chan receive", "leaktest.blockedForever("} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestCheckWaitsForExit(t *testing.T) {
+	ft := &fakeT{}
+	check := Check(ft, Options{Timeout: time.Second})
+	go time.Sleep(50 * time.Millisecond) // exits well within the timeout
+	check()
+	if len(ft.errors) > 0 {
+		t.Errorf("got failures for a goroutine that exited in time: %q", ft.errors)
+	}
+}
+
+func TestCheckIgnore(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	ft := &fakeT{}
+	check := Check(ft, Options{Timeout: 50 * time.Millisecond, Ignore: []string{"leaktest.blockedForever("}})
+	go blockedForever(done)
+	check()
+	if len(ft.errors) > 0 {
+		t.Errorf("got failures for an ignored goroutine: %q", ft.errors)
+	}
+}
+
+func TestLeakedBefore(t *testing.T) {
+	// Goroutines already running at the snapshot are not leaks, whatever they do later.
+	done := make(chan struct{})
+	defer close(done)
+	go blockedForever(done)
+	before := Snapshot()
+	if leaked := Leaked(before, Options{Timeout: 10 * time.Millisecond}); len(leaked) > 0 {
+		t.Error(Report(leaked))
+	}
+}
+
+func TestParse(t *testing.T) {
+	dump := `goroutine 1 [running]:
+main.main()
+	/tmp/main.go:5 +0x1d
+
+goroutine 18 [chan send, 2 minutes]:
+main.walk(0xc000010000)
+	/tmp/main.go:12 +0x45
+created by main.main in goroutine 1
+	/tmp/main.go:4 +0x1a
+
+not a goroutine
+`
+	gs := parse([]byte(dump))
+	if len(gs) != 2 {
+		t.Fatalf("got %d goroutines, want 2: %+v", len(gs), gs)
+	}
+	if g := gs[0]; g.ID != 1 || g.State != "running" || !strings.HasPrefix(g.Stack, "goroutine 1 [running]:") {
+		t.Errorf("first: got %+v", g)
+	}
+	if g := gs[1]; g.ID != 18 || g.State != "chan send" || !strings.Contains(g.Stack, "main.walk(") {
+		t.Errorf("second: got %+v", g)
+	}
+}
+
+func TestSnapshotExcludesSelf(t *testing.T) {
+	self := currentID()
+	if self == 0 {
+		t.Fatal("no ID for the current goroutine")
+	}
+	for _, g := range Snapshot() {
+		if g.ID == self {
+			t.Errorf("the calling goroutine %d is in the snapshot", self)
+		}
+	}
+}
@@ -1,24 +1,30 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"golang.org/x/tour/tree"
 	"strconv"
 	"time"
+
+	"golang-demo/clock"
+	"golang-demo/leaktest"
+	"golang-demo/tree"
 )
 
-func say(s string) {
+// The functions below wait through a clock.Clock instead of calling the time package directly:
+// main passes clock.Real, a test can pass a clock.Fake and run them instantly (see clock/fake.go).
+func say(clk clock.Clock, s string) {
 	for i := 0; i < 5; i++ {
-		time.Sleep(100 * time.Millisecond)
+		clk.Sleep(100 * time.Millisecond)
 		fmt.Println(s)
 	}
 }
 
-func sum(s []int, c chan int) {
+func sum(clk clock.Clock, s []int, c chan int) {
 	sum := 0
 	for _, v := range s {
 		sum += v
-		time.Sleep(100 * time.Millisecond)
+		clk.Sleep(100 * time.Millisecond)
 	}
 	c <- sum // send sum to c
 }
@@ -47,9 +53,12 @@ func fibSelect(c, quit chan int) {
 	}
 }
 
-func ClockBoom() {
-	tick := time.Tick(100 * time.Millisecond)
-	boom := time.After(500 * time.Millisecond)
+func ClockBoom(clk clock.Clock) {
+	// Unlike Tick, a ticker can be stopped: it doesn't keep ticking once we return.
+	ticker := clk.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	tick := ticker.C()
+	boom := clk.After(500 * time.Millisecond)
 	for {
 		select {
 		case <-tick:
@@ -59,39 +68,65 @@ func ClockBoom() {
 			return
 		default:
 			fmt.Println("    .")
-			time.Sleep(50 * time.Millisecond)
+			clk.Sleep(50 * time.Millisecond)
 		}
 	}
 }
 
 // ******** Exercise! *********
-func _WalkTree(t *tree.Tree, ch chan int, isRoot bool) {
-	// Walks the tree t sending all values from the tree to the channel ch.
-	if t.Right != nil {
-		_WalkTree(t.Right, ch, false)
+func _WalkTree(ctx context.Context, t *tree.Node[int], ch chan int) bool {
+	// Walks the tree t sending all values from the tree to the channel ch, in ascending order (left, root, right).
+	// Returns false if ctx is done before all the values have been sent.
+	if t == nil {
+		return true
 	}
-	ch <- t.Value
-	if t.Left != nil {
-		_WalkTree(t.Left, ch, false)
+	if !_WalkTree(ctx, t.Left, ch) {
+		return false
 	}
-	if isRoot {
-		close(ch)
+	select {
+	case ch <- t.Value:
+	case <-ctx.Done():
+		return false
 	}
+	return _WalkTree(ctx, t.Right, ch)
 }
 
-func WalkTree(t *tree.Tree, ch chan int) {
-	_WalkTree(t, ch, true)
+func WalkTree(ctx context.Context, t *tree.Tree[int], ch chan int) {
+	defer close(ch)
+	_WalkTree(ctx, t.Root(), ch)
 }
 
-func SameTree(t1, t2 *tree.Tree) bool {
+func SameTree(t1, t2 *tree.Tree[int]) bool {
+	// When we return early (at the first difference), the walkers would block forever on their next send.
+	// Cancelling the context on return lets them exit instead of leaking.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	c1 := make(chan int)
 	c2 := make(chan int)
-	go WalkTree(t1, c1)
-	go WalkTree(t2, c2)
+	go WalkTree(ctx, t1, c1)
+	go WalkTree(ctx, t2, c2)
 	for {
 		i1, ok1 := <-c1
 		i2, ok2 := <-c2
-		if i1 != i2 || ok1 != ok2{
+		if i1 != i2 || ok1 != ok2 {
+			return false
+		} else if !ok1 {
+			return true
+		}
+	}
+}
+
+// SameTreePull does the same with pull iterators: the caller asks for each value, no walker runs ahead.
+// Each Pull still runs its walk in a coroutine, which the deferred stops end when we return early.
+func SameTreePull(t1, t2 *tree.Tree[int]) bool {
+	next1, stop1 := t1.Pull()
+	defer stop1()
+	next2, stop2 := t2.Pull()
+	defer stop2()
+	for {
+		i1, ok1 := next1()
+		i2, ok2 := next2()
+		if i1 != i2 || ok1 != ok2 {
 			return false
 		} else if !ok1 {
 			return true
@@ -103,8 +138,8 @@ func main() {
 	// A goroutine is a lightweight thread managed by the Go runtime. Format is `go func(x,y,z)`
 	// Note the evaluation of `f`, `x`, `y`, and `z` happens in the current goroutine and the execution of `f` happens in the new goroutine.
 	// Goroutines run in the same address space, so access to shared memory must be synchronized (like use `channel`).
-	go say("world")
-	say("hello")
+	go say(clock.Real, "world")
+	say(clock.Real, "hello")
 
 	// Channels are a typed conduit through which you can send and receive values with the channel operator, <-.
 	// (The data flows in the direction of the arrow.)
@@ -114,8 +149,8 @@ func main() {
 	// Channels can be buffered. Provide the buffer length as the second argument to `make`.
 	// (类似于Python中Queue的max size，buffer满了会block要进入channel的goroutine)
 	c := make(chan int, 2)
-	go sum(s[:len(s)/2], c)
-	go sum(s[len(s)/2:], c)
+	go sum(clock.Real, s[:len(s)/2], c)
+	go sum(clock.Real, s[len(s)/2:], c)
 	x, y := <-c, <-c // receive from c
 	fmt.Println(x, y, x+y)
 
@@ -140,10 +175,61 @@ func main() {
 	fibSelect(e, quit)
 
 	// The default case in a select is run if no other case is ready.
-	ClockBoom()
+	ClockBoom(clock.Real)
+
+	// With a fake clock, time only moves when we advance it: ClockBoom runs without waiting.
+	// BlockUntil(3) waits for ClockBoom to be sleeping in its default case (ticker + boom timer + sleep)
+	// before each step of 50ms. The ticks and the sleeps still fall due at the same instants (100ms, 200ms...),
+	// so the order of "tick." and "." may differ between runs, see clock/example_test.go for a steady output.
+	fake := clock.NewFake(time.Now())
+	done := make(chan struct{})
+	go func() {
+		ClockBoom(fake)
+		close(done)
+	}()
+	for i := 0; i < 10; i++ {
+		fake.BlockUntil(3)
+		fake.Advance(50 * time.Millisecond)
+	}
+	<-done
 
 	// ******** Exercise! *********
-	tree1 := tree.New(1)
-	tree2 := tree.New(2)
-	fmt.Println(SameTree(tree1, tree2))
+	// tree.NewRandom(k) builds a tree holding k, 2k, ..., 10k (see tree/tree.go)
+	tree1 := tree.NewRandom(1)
+	tree2 := tree.NewRandom(2)
+	before := leaktest.Snapshot()
+	fmt.Println(SameTree(tree1, tree2), SameTree(tree1, tree.NewRandom(1)))
+	fmt.Println(SameTreePull(tree1, tree2), SameTreePull(tree1, tree.NewRandom(1)))
+	// The walkers need a moment to notice the cancellation (Leaked waits for them), then no goroutine is left behind.
+	fmt.Println("leaked goroutines:", len(leaktest.Leaked(before, leaktest.Options{})))
+
+	// Without a way to stop it, fibSelect blocks forever once nobody receives from e2 any more: it leaks.
+	before = leaktest.Snapshot()
+	e2 := make(chan int)
+	go fibSelect(e2, make(chan int))
+	fmt.Println(<-e2, <-e2, <-e2)
+	leaked := leaktest.Leaked(before, leaktest.Options{Timeout: 50 * time.Millisecond})
+	for _, g := range leaked {
+		fmt.Printf("goroutine %d leaked, blocked in %s\n", g.ID, g.State)
+	}
+
+	// The tree package can walk a tree in other orders too, as a channel stream or with a callback.
+	for _, order := range []tree.Order{tree.InOrder, tree.ReverseOrder, tree.PreOrder, tree.PostOrder, tree.LevelOrder} {
+		ch := make(chan int)
+		go tree.WalkOrder(context.Background(), tree1, order, ch)
+		fmt.Print(order, ":")
+		for v := range ch {
+			fmt.Print(" ", v)
+		}
+		fmt.Println()
+	}
+	tree1.Visit(tree.PreOrder, func(v int) bool {
+		fmt.Print(v, " ")
+		return v != 4 // stop the walk at 4
+	})
+	fmt.Println()
+
+	// Two trees holding the same values may still have different shapes.
+	tree3 := tree.NewRandom(1)
+	fmt.Println(tree.SameValues(tree1, tree3), tree.SameShape(tree1, tree3))
 }
@@ -1,38 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	neturl "net/url"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
-)
-
-// SafeCounter is safe to use concurrently.
-type SafeCounter struct {
-	v   map[string]int
-	mux sync.Mutex
-}
-
-// Inc increments the counter for the given key.
-func (c *SafeCounter) Inc(key string) {
-	c.mux.Lock()
-	// Lock so only one goroutine at a time can access the map c.v.
-	c.v[key]++
-	time.Sleep(time.Millisecond)
-	c.mux.Unlock()
-}
 
-// Value returns the current value of the counter for the given key.
-func (c *SafeCounter) Value(key string) int {
-	c.mux.Lock()
-	// Lock so only one goroutine at a time can access the map c.v.
-	defer c.mux.Unlock()
-	return c.v[key]
-}
+	"golang-demo/clock"
+	"golang-demo/counter"
+	"golang-demo/ratelimit"
+	"golang-demo/syncx"
+)
 
-func MyPrint(i int, wg *sync.WaitGroup) {
-	defer wg.Done()
+func MyPrint(clk clock.Clock, i int) error {
 	fmt.Println(i)
-	time.Sleep(time.Millisecond)
+	clk.Sleep(time.Millisecond)
+	return nil
 }
 
 // ******** Exercise! *********
@@ -47,10 +34,22 @@ type Fetcher interface {
 var crawlUrl = map[string]bool{}
 var mutex sync.Mutex
 
-func Crawl(url string, depth int, fetcher Fetcher, wg *sync.WaitGroup) {
+// Be polite with the crawled sites: at most 2 fetches in a row per host, then one every 10ms.
+var hostLimits = ratelimit.NewKeyed(func(string) ratelimit.Limiter {
+	return ratelimit.NewTokenBucket(10*time.Millisecond, 2, nil)
+}, nil)
+
+func host(url string) string {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return url
+	}
+	return u.Host
+}
+
+func Crawl(ctx context.Context, g *syncx.Group, url string, depth int, fetcher Fetcher) error {
 	// TODO: Fetch URLs in parallel.
 	// TODO: Don't fetch the same URL twice.
-	defer wg.Done()
 	mutex.Lock()
 	_, ok := crawlUrl[url]
 	if !ok {
@@ -59,21 +58,26 @@ func Crawl(url string, depth int, fetcher Fetcher, wg *sync.WaitGroup) {
 	mutex.Unlock()
 
 	if depth <= 0 || ok {
-		return
+		return nil
+	}
+	if err := hostLimits.Wait(ctx, host(url)); err != nil {
+		return err // the crawl was cancelled
 	}
 	body, urls, err := fetcher.Fetch(url)
 	//fmt.Println(urls)
 	if err != nil {
+		// A missing page is not a reason to stop the whole crawl.
 		fmt.Println(err)
-		return
+		return nil
 	}
 
 	fmt.Printf("found: %s %q\n", url, body)
 	for _, u := range urls {
-		wg.Add(1)
-		go Crawl(u, depth-1, fetcher, wg)
+		g.Go(func() error {
+			return Crawl(ctx, g, u, depth-1, fetcher)
+		})
 	}
-	return
+	return nil
 }
 
 // fakeFetcher is Fetcher that returns canned results.
@@ -127,8 +131,8 @@ var fetcher = fakeFetcher{
 
 func main() {
 	// We can define a block of code to be executed in mutual exclusion by surrounding it with a call to Lock and Unlock.
-	// We can also use defer to ensure the mutex will be unlocked as in the Value method.
-	c := SafeCounter{v: make(map[string]int)}
+	// We can also use defer to ensure the mutex will be unlocked as in the Value method. (See counter/safe.go)
+	c := counter.NewSafeCounterWithClock(clock.Real) // Inc naps 1ms on the clock with the lock held
 	for i := 0; i < 10; i++ {
 		go c.Inc("somekey") // 10 goroutines start at the same time
 	}
@@ -137,20 +141,97 @@ func main() {
 	fmt.Println(c.Value("somekey"))
 	fmt.Println("---")
 
+	// The counts can be saved to disk and restored later, e.g. when the process restarts.
+	dir, _ := os.MkdirTemp("", "counter")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "counts.bin")
+	if err := c.Save(path, counter.Binary); err != nil {
+		fmt.Println(err)
+	}
+	restored, err := counter.Load(path)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println(restored.Value("somekey"))
+	}
+	fmt.Println("---")
+
+	// To see how much time goroutines spend waiting for the lock, guard the counter with an instrumented mutex.
+	mux := new(syncx.Mutex)
+	c2 := counter.NewSafeCounterWithLocker(mux)
+	var wg0 sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg0.Add(1)
+		go func() {
+			defer wg0.Done()
+			c2.Inc("somekey")
+		}()
+	}
+	wg0.Wait()
+	stats := mux.Stats()
+	fmt.Println(c2.Value("somekey"), "acquisitions:", stats.Acquisitions, "contended:", stats.Contentions)
+	fmt.Print("wait ", stats.Wait)
+	fmt.Println("---")
+
+	// Several keys can be updated together: either all the changes of a batch are applied, or none.
+	stock := counter.NewSafeCounter()
+	stock.Add("apples", 1)
+	order := counter.NewBatch().
+		AddIf("apples", -2, func(v int) bool { return v >= 2 }).
+		Inc("orders")
+	if err := stock.Apply(order); err != nil {
+		fmt.Println(err) // counter: conditional add -2 on "apples" failed, value is 1
+	}
+	fmt.Println(stock.Values("apples", "orders")) // map[apples:1 orders:0]
+	fmt.Println("---")
+
 	// Use sync.WaitGroup to wait for all goroutines finished.
 	// (Go没有像Python中多线程的join那样直接的方法，我们需要手动设置一个计数器（即sync.WaitGroup），一般会在goroutine外计数加一，
 	// 而在goroutine内使用`defer wg.Done()`，即函数返回之后计数减一)
-	var wg sync.WaitGroup
+	// syncx.Group does the counting for us, and also collects the errors of the goroutines.
+	// SetLimit(3) lets at most 3 of them run at the same time.
+	g, _ := syncx.NewGroup(context.Background())
+	g.SetLimit(3)
 	for i := 0; i < 10; i++ {
-		wg.Add(1)
-		go MyPrint(i, &wg)
+		g.Go(func() error {
+			return MyPrint(clock.Real, i)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println("---")
+
+	// A panic in a goroutine of the group doesn't crash the process: it becomes the error returned by Wait,
+	// with the stack of the goroutine, and cancels the context of the other goroutines.
+	g1, ctx1 := syncx.NewGroup(context.Background())
+	g1.Go(func() error {
+		var m map[string]int
+		m["boom"]++ // assignment to entry in nil map
+		return nil
+	})
+	g1.Go(func() error {
+		<-ctx1.Done()
+		return ctx1.Err()
+	})
+	if err := g1.Wait(); err != nil {
+		var perr *syncx.PanicError
+		if errors.As(err, &perr) {
+			fmt.Println("recovered:", perr.Value)
+		}
 	}
-	wg.Wait()
 	fmt.Println("---")
 
 	// ******** Exercise! *********
-	var wg2 sync.WaitGroup
-	wg2.Add(1)
-	Crawl("https://golang.org/", 4, fetcher, &wg2)
-	wg2.Wait()
+	// No limit here: the crawling goroutines start new ones, with a limit they could end up all waiting for a slot.
+	g2, ctx2 := syncx.NewGroup(context.Background())
+	start := time.Now()
+	g2.Go(func() error {
+		return Crawl(ctx2, g2, "https://golang.org/", 4, fetcher)
+	})
+	if err := g2.Wait(); err != nil {
+		fmt.Println(err)
+	}
+	// 5 fetches on golang.org: 2 right away, then 3 more 10ms apart.
+	fmt.Println("crawled in", time.Since(start).Round(10*time.Millisecond))
 }
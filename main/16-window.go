@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"golang-demo/clock"
+	"golang-demo/counter"
+)
+
+func main() {
+	// A Window counter only remembers the events of the last minute, in 1-second buckets.
+	// clock.Fake is a clock whose time only moves when we say so, it makes the demo deterministic.
+	clk := clock.NewFake(time.Date(2019, 3, 18, 0, 0, 0, 0, time.UTC))
+	w := counter.NewWindow(time.Minute, time.Second, clk)
+
+	for i := 0; i < 30; i++ {
+		w.Inc("GET /")
+		clk.Advance(time.Second)
+	}
+	fmt.Println(w.Value("GET /"))                      // 30
+	fmt.Println(w.Count("GET /", 10*time.Second))      // the last 10 buckets (the current one is empty): 9
+	fmt.Println(w.Rate("GET /", 10*time.Second), "/s") // 0.9 /s
+
+	// After another 45 seconds, the first 16 events have slid out of the window.
+	clk.Advance(45 * time.Second)
+	fmt.Println(w.Value("GET /")) // 14
+
+	// Once a key has no events left in the window, Evict frees its buckets.
+	clk.Advance(time.Minute)
+	fmt.Println(w.Value("GET /"), w.Evict(), w.Keys())
+}
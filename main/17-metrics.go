@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"golang-demo/counter"
+	"golang-demo/metrics"
+)
+
+func main() {
+	requests := counter.NewSafeCounter()
+	recent := counter.NewWindow(time.Minute, time.Second, nil)
+	for _, key := range []string{"GET /", "GET /", "POST /login", "GET /users"} {
+		requests.Inc(key)
+		recent.Inc(key)
+	}
+
+	// Both counter types have a `Snapshot() map[string]int` method, so both satisfy metrics.Collector.
+	// The LabelFunc turns each key into Prometheus labels.
+	reg := metrics.NewRegistry()
+	reg.Register(metrics.Desc{
+		Name:   "http_requests_total",
+		Help:   "Requests served since start.",
+		Kind:   metrics.Counter,
+		Labels: metrics.SplitLabels(" ", "method", "route"),
+	}, requests)
+	reg.Register(metrics.Desc{
+		Name: "http_requests_last_minute",
+		Help: "Requests served in the last minute.",
+		Kind: metrics.Gauge,
+	}, recent)
+	reg.WritePrometheus(os.Stdout)
+
+	// A Registry is an http.Handler, a real service would do `http.Handle("/metrics", reg)`.
+	// Here we call it directly and ask for the JSON view.
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics?format=json", nil))
+	fmt.Print(rec.Body.String())
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+
+	"golang-demo/counter"
+)
+
+func main() {
+	// SafeCounter keeps one map entry per key. When keys are unbounded (user IDs, URLs...)
+	// we can trade exactness for bounded memory.
+	// A Count-Min Sketch of ~0.1% error with 99% confidence is a fixed table of 5 x 2719 ints, whatever the number of keys.
+	sketch := counter.NewCountMin(0.001, 0.01)
+	// Space-Saving only remembers 50 keys, enough to find the 5 most frequent ones.
+	top := counter.NewTopK(50)
+
+	zipf := rand.NewZipf(rand.New(rand.NewSource(42)), 1.5, 1, 1000000)
+	exact := map[string]int{}
+	for i := 0; i < 100000; i++ {
+		key := "user-" + strconv.FormatUint(zipf.Uint64(), 10)
+		sketch.Inc(key)
+		top.Inc(key)
+		exact[key]++
+	}
+	fmt.Println(len(exact), "distinct keys,", sketch.Width(), "x", sketch.Depth(), "sketch")
+	for _, e := range top.Top()[:5] {
+		fmt.Printf("%-8s exact=%d sketch=%d top=%d (±%d)\n", e.Key, exact[e.Key], sketch.Value(e.Key), e.Count, e.Err)
+	}
+
+	// Sketches of the same size built in different places (e.g. one per goroutine) can be merged.
+	other := counter.NewCountMin(0.001, 0.01)
+	other.Add("user-1", 10)
+	sketch.Merge(other)
+	fmt.Println(sketch.Value("user-1"), exact["user-1"]+10)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"golang-demo/pipeline"
+)
+
+func main() {
+	// The `sum` and `fib` demos wire goroutines and channels by hand.
+	// The pipeline package provides the usual stages, each running in its own goroutine(s):
+	// a stage reads the channel of the previous one and returns a new channel.
+	p := pipeline.New(context.Background())
+	numbers := pipeline.Source(p, 7, 2, 8, -9, 4, 0)
+	positive := pipeline.Filter(p, numbers, func(n int) bool { return n > 0 })
+	// FanOut runs the function on 3 goroutines, Ordered keeps the results in input order.
+	squares := pipeline.FanOut(p, positive, 3, pipeline.Ordered, func(n int) (int, error) {
+		return n * n, nil
+	})
+	pairs := pipeline.Batch(p, squares, 2)
+	err := pipeline.Sink(p, pairs, func(batch []int) error {
+		fmt.Println(batch)
+		return nil
+	})
+	fmt.Println("err:", err)
+
+	// The first error stops every stage of the pipeline, and is what Sink (or Wait) returns.
+	p = pipeline.New(context.Background())
+	words := pipeline.Source(p, "1", "2", "three", "4")
+	parsed := pipeline.Map(p, words, strconv.Atoi)
+	sum := 0
+	err = pipeline.Sink(p, parsed, func(n int) error {
+		sum += n
+		return nil
+	})
+	fmt.Println(sum, err)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+
+	"golang-demo/parallel"
+)
+
+func main() {
+	// The `sum` demo splits a slice into two halves by hand.
+	// parallel.Reduce splits it into one chunk per worker, sums every chunk in its own goroutine,
+	// then adds up the partial sums.
+	s := []int{7, 2, 8, -9, 4, 0}
+	total, _ := parallel.Reduce(context.Background(), s, 2, 0, func(a, b int) int { return a + b })
+	fmt.Println(total)
+
+	// Compare with a sequential loop on a heavier computation.
+	values := make([]float64, 5000000)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	work := func(v float64) (float64, error) {
+		return math.Sqrt(v) * math.Sin(v), nil
+	}
+	add := func(a, b float64) float64 { return a + b }
+
+	start := time.Now()
+	sequential := 0.0
+	for _, v := range values {
+		r, _ := work(v)
+		sequential = add(sequential, r)
+	}
+	fmt.Printf("sequential: %.2f in %v\n", sequential, time.Since(start))
+
+	start = time.Now()
+	// 0 workers means one per CPU (GOMAXPROCS).
+	result, err := parallel.MapReduce(context.Background(), values, 0, work, 0, add)
+	fmt.Printf("%d workers: %.2f in %v (%v)\n", runtime.GOMAXPROCS(0), result, time.Since(start), err)
+	// The two sums may differ in the last digits: floating point addition is only approximately associative.
+
+	// Cancelling the context (or a failing mapper) stops all the workers.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = parallel.MapReduce(ctx, values, 0, func(v float64) (float64, error) {
+		time.Sleep(time.Microsecond)
+		return v, nil
+	}, 0, add)
+	fmt.Println(err)
+}
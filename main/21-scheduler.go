@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang-demo/scheduler"
+)
+
+func main() {
+	// ClockBoom with a scheduler: the jobs run on a pool of 2 worker goroutines.
+	s := scheduler.New(2, nil)
+	tick := s.Every(100*time.Millisecond, func(ctx context.Context) {
+		fmt.Println("tick.")
+	})
+	boom := make(chan struct{})
+	s.After(500*time.Millisecond, func(ctx context.Context) {
+		fmt.Println("BOOM!")
+		close(boom)
+	})
+	<-boom
+	// Cancelling a job removes it from the scheduler.
+	tick.Cancel()
+
+	// Jobs can also follow cron expressions ("minute hour day-of-month month day-of-week"),
+	// and be spread with a random jitter. Here: every 5 minutes during working hours.
+	sched, err := scheduler.Cron("*/5 9-17 * * 1-5")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	report := s.Add(sched, func(ctx context.Context) {
+		fmt.Println("report")
+	}, scheduler.Options{Jitter: 10 * time.Second, Missed: scheduler.Skip})
+	next, _ := report.Next()
+	fmt.Println("next report at", next.Format(time.RFC1123))
+
+	s.Stop()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"golang-demo/generator"
+)
+
+func main() {
+	// A generator is a lazy sequence: nothing is computed until a consumer asks for values.
+	// Take, Skip and TakeWhile build new generators out of existing ones.
+	fmt.Println(generator.Fibonacci().Take(10).Slice())
+	fmt.Println(generator.Primes().Skip(5).Take(5).Slice())
+	fmt.Println(generator.Range(10, 0, -3).Slice())
+
+	// Pushed through a channel, like `fib` in the goroutines demo.
+	// Instead of a separate `quit` channel, cancelling the context stops the producing goroutine.
+	ctx, cancel := context.WithCancel(context.Background())
+	for v := range generator.Fibonacci().Chan(ctx) {
+		if v > 100 {
+			break
+		}
+		fmt.Print(v, " ")
+	}
+	cancel()
+	fmt.Println()
+
+	// Pulled one at a time: iter.Pull runs the generator in a coroutine that only moves when Next asks,
+	// Stop ends it if we leave before the values are exhausted.
+	it := generator.Primes().TakeWhile(func(p int) bool { return p < 30 }).Iter()
+	defer it.Stop()
+	for p, ok := it.Next(); ok; p, ok = it.Next() {
+		fmt.Print(p, " ")
+	}
+	fmt.Println()
+
+	squares := generator.Map(generator.Count(1), func(n int) int { return n * n })
+	for v := range squares.Take(5).All() {
+		fmt.Print(v, " ")
+	}
+	fmt.Println()
+}
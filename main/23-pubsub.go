@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"golang-demo/pubsub"
+)
+
+func main() {
+	// The goroutine demos pass values point to point. With a broker, publishers and subscribers
+	// only share topic names: every subscriber whose pattern matches the topic gets its own copy.
+	b := pubsub.NewBroker[string]()
+	defer b.Close()
+
+	// `*` matches one word of the topic, a final `>` matches the rest.
+	audit, _ := b.Subscribe("orders.>", 10, pubsub.Block)
+	// A slow consumer that only cares about the latest values: when its buffer of 2 is full,
+	// the oldest message is dropped to make room.
+	dashboard, _ := b.Subscribe("orders.*", 2, pubsub.DropOldest)
+
+	b.Publish("orders.created", "#1")
+	b.Publish("orders.created", "#2")
+	b.Publish("orders.eu.created", "#3") // not matched by "orders.*"
+	b.Publish("orders.paid", "#1")
+
+	audit.Unsubscribe() // closes the channel after the buffered messages
+	for m := range audit.C() {
+		fmt.Println("audit:", m.Topic, m.Value)
+	}
+	dashboard.Unsubscribe()
+	for m := range dashboard.C() {
+		fmt.Println("dashboard:", m.Topic, m.Value)
+	}
+	fmt.Printf("%+v\n", dashboard.Stats())
+	fmt.Printf("%+v\n", b.Stats())
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang-demo/syncx"
+)
+
+// conn stands for a resource that is expensive to open, like a network connection.
+type conn struct {
+	id     int
+	broken bool
+}
+
+func main() {
+	// The crawler of 15-mutex.go runs any number of fetches at once. With a weighted semaphore, we can bound
+	// the cost of the fetches in progress instead: a big page weighs more than a small one.
+	sem := syncx.NewSemaphore(10)
+	sizes := []int64{8, 2, 5, 1, 3, 6, 2}
+	var inFlight, peak atomic.Int64
+	var wg sync.WaitGroup
+	for _, size := range sizes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sem.Acquire(context.Background(), size); err != nil {
+				fmt.Println(err)
+				return
+			}
+			defer sem.Release(size)
+			cur := inFlight.Add(size)
+			for p := peak.Load(); cur > p && !peak.CompareAndSwap(p, cur); p = peak.Load() {
+			}
+			time.Sleep(time.Millisecond) // fetch the page
+			inFlight.Add(-size)
+		}()
+	}
+	wg.Wait()
+	fmt.Println("peak weight in flight:", peak.Load()) // at most 10
+
+	// Acquire gives up when its context is done.
+	sem.Acquire(context.Background(), 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	fmt.Println(sem.Acquire(ctx, 1)) // context deadline exceeded
+	cancel()
+	sem.Release(10)
+	fmt.Println("---")
+
+	// A pool keeps connections for reuse instead of opening one per request.
+	var opened atomic.Int32
+	pool := syncx.NewPool(syncx.PoolOptions[*conn]{
+		Create: func(ctx context.Context) (*conn, error) {
+			return &conn{id: int(opened.Add(1))}, nil
+		},
+		Validate: func(c *conn) bool { return !c.broken },
+		Destroy:  func(c *conn) { fmt.Println("closing conn", c.id) },
+		MaxOpen:  3,
+		MaxIdle:  3,
+	})
+	var wg2 sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg2.Add(1)
+		go func() {
+			defer wg2.Done()
+			r, err := pool.Get(context.Background())
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			time.Sleep(time.Millisecond) // use r.Value
+			r.Release()
+		}()
+	}
+	wg2.Wait()
+	// No more than 3 connections are open at once, and with MaxIdle 3 they are all kept for reuse.
+	fmt.Println("20 requests served with", opened.Load(), "connections")
+	fmt.Printf("%+v\n", pool.Stats())
+
+	// A broken connection is not handed out again.
+	r, _ := pool.Get(context.Background())
+	r.Value.broken = true
+	r.Release()
+	r, _ = pool.Get(context.Background())
+	fmt.Println("got conn", r.Value.id)
+	r.Release()
+	pool.Close()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang-demo/actor"
+	"golang-demo/counter"
+)
+
+// parser is an actor that fails on bad input. Its hooks print its lifecycle.
+type parser struct {
+	id  int
+	sum int
+}
+
+func (p *parser) Receive(self *actor.Ref[string], msg string) error {
+	n, err := strconv.Atoi(msg)
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		panic("negative number") // a panic is a failure too
+	}
+	p.sum += n
+	fmt.Printf("%s#%d: sum %d\n", self.Name(), p.id, p.sum)
+	return nil
+}
+
+func (p *parser) PreStart() error {
+	fmt.Printf("parser#%d: started\n", p.id)
+	return nil
+}
+
+func (p *parser) PreRestart(reason error) {
+	var perr *actor.PanicError
+	if errors.As(reason, &perr) {
+		reason = fmt.Errorf("panic: %v", perr.Value) // without the stack
+	}
+	fmt.Printf("parser#%d: restarting after %v\n", p.id, reason)
+}
+
+func (p *parser) PostStop() {
+	fmt.Printf("parser#%d: stopped\n", p.id)
+}
+
+func main() {
+	// SafeCounter again, but the map is owned by one goroutine and the others send it messages, no mutex.
+	c := counter.NewActorCounter(nil, time.Second)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Inc("somekey")
+		}()
+	}
+	wg.Wait()
+	fmt.Println(c.Value("somekey")) // 10 <nil>
+	c.Stop()
+	fmt.Println(c.Value("somekey")) // 0 actor: stopped
+	fmt.Println("---")
+
+	// A supervisor restarts the failed actor from a fresh instance, the messages in its mailbox are kept.
+	// After more than 2 failures within a minute, it gives up and stops the actor for good.
+	sup := actor.NewSupervisor(actor.OneForOne, 2, time.Minute)
+	instances := 0
+	p := actor.Spawn(func() actor.Actor[string] {
+		instances++
+		return &parser{id: instances}
+	}, actor.Options{Name: "parser", Mailbox: 10, Supervisor: sup})
+	for _, msg := range []string{"1", "2", "x", "3", "-1", "4", "y", "5"} {
+		p.Send(msg)
+	}
+	<-p.Done()
+	fmt.Println(sup.Err())
+}
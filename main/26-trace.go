@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang-demo/trace"
+)
+
+// tracedSay is `say` of 14-goroutines.go, recording its sleeps and prints in tr.
+func tracedSay(tr *trace.Tracer, s string) {
+	tr.Label(s)
+	for i := 0; i < 5; i++ {
+		sp := tr.Begin("sleep")
+		time.Sleep(100 * time.Millisecond)
+		sp.End()
+		tr.Event("print " + s)
+		fmt.Println(s)
+	}
+}
+
+// tracedSum is `sum` of 14-goroutines.go, the part it adds up is a span, and so is the wait to send the result.
+func tracedSum(tr *trace.Tracer, name string, s []int, c chan int) {
+	tr.Label(name)
+	sp := tr.Begin("add")
+	sum := 0
+	for _, v := range s {
+		sum += v
+		time.Sleep(10 * time.Millisecond)
+	}
+	sp.End()
+	defer tr.Begin("send").End()
+	c <- sum
+}
+
+func main() {
+	// go say("world") and say("hello") print in turns: the timeline shows their sleeps overlap
+	// and the prints (*) alternate.
+	tr := trace.New(nil)
+	go tracedSay(tr, "world")
+	tracedSay(tr, "hello")
+	time.Sleep(10 * time.Millisecond) // let "world" finish, main doesn't wait for it
+	tr.WriteText(os.Stdout, 60)
+	fmt.Println("---")
+
+	// The fan-out of sum: both halves are added at the same time, then each goroutine waits
+	// until main receives its result (unbuffered channel).
+	tr.Reset()
+	tr.Label("main")
+	s := []int{7, 2, 8, -9, 4, 0, 5, 1}
+	c := make(chan int)
+	go tracedSum(tr, "sum 1", s[:len(s)/2], c)
+	go tracedSum(tr, "sum 2", s[len(s)/2:], c)
+	time.Sleep(60 * time.Millisecond)
+	x := <-c
+	tr.Event("received")
+	time.Sleep(20 * time.Millisecond)
+	y := <-c
+	tr.Event("received")
+	fmt.Println(x, y, x+y)
+	tr.WriteText(os.Stdout, 60)
+
+	// The same as an HTML page, to open in a browser.
+	f, err := os.CreateTemp("", "sum-trace-*.html")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer f.Close()
+	if err := tr.WriteHTML(f, "sum fan-out"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("wrote", f.Name())
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"golang-demo/metrics"
+	"golang-demo/queue"
+)
+
+func produce(q *queue.Queue[int], s []int) {
+	for _, v := range s {
+		q.Put(context.Background(), v) // blocks while the queue is full, like a send on a buffered channel
+	}
+}
+
+func main() {
+	// The `sum` demo with a queue instead of `make(chan int, 2)`: two producers, a slow consumer.
+	q := queue.New[int](2)
+	s := []int{7, 2, 8, -9, 4, 0}
+	done := make(chan struct{})
+	go func() {
+		produce(q, s[:len(s)/2])
+		done <- struct{}{}
+	}()
+	go func() {
+		produce(q, s[len(s)/2:])
+		done <- struct{}{}
+	}()
+	go func() {
+		<-done
+		<-done
+		q.Close() // no more values, like close(c)
+	}()
+	sum := 0
+	for v := range q.All(context.Background()) {
+		time.Sleep(5 * time.Millisecond) // slow consumer: the producers wait for room
+		sum += v
+	}
+	fmt.Println("sum:", sum)
+	st := q.Stats()
+	fmt.Printf("puts %d, high water %d/%d, blocked puts %d waiting %v on average\n",
+		st.Puts, st.HighWater, st.Cap, st.PutWait.Count, st.PutWait.Mean().Round(time.Millisecond))
+	fmt.Println("---")
+
+	// TryPut never blocks: when the queue is full the value is dropped and counted.
+	events := queue.New[string](3)
+	for i := 0; i < 5; i++ {
+		if err := events.TryPut(fmt.Sprint("event ", i)); err != nil {
+			fmt.Println(err)
+		}
+	}
+	// Get gives up when its context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	for {
+		v, err := events.Get(ctx)
+		if err != nil {
+			fmt.Println(err) // context deadline exceeded
+			break
+		}
+		fmt.Println(v)
+	}
+
+	// A queue is a metrics.Collector, its statistics can be exported like the counters.
+	reg := metrics.NewRegistry()
+	reg.Register(metrics.Desc{Name: "events_queue", Help: "Events queue statistics.", Kind: metrics.Gauge, Labels: metrics.KeyLabel("stat")}, events)
+	reg.WritePrometheus(os.Stdout)
+}
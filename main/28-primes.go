@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"golang-demo/primes"
+)
+
+// timeIt runs fn and prints how long it took.
+func timeIt(name string, fn func() int) {
+	start := time.Now()
+	n := fn()
+	fmt.Printf("%-32s %8d primes in %v\n", name, n, time.Since(start).Round(time.Microsecond))
+}
+
+func main() {
+	fmt.Println(primes.Sieve(50))
+	fmt.Println(primes.DaisyChain(50))
+	fmt.Println(primes.First(10))
+	fmt.Println(primes.IsPrime(1_000_000_007), primes.IsPrime(1_000_000_011))
+	fmt.Println(primes.Factor(360), primes.Factor(600851475143))
+	// Pollard's rho finds the two 30-bit factors at once, trial division would need ~30000 divisions.
+	fmt.Println(primes.Factor(998244353 * 1000000007))
+	fmt.Println("---")
+
+	// How the ways of listing the primes compare, on bounds suited to each.
+	const n = 20_000_000
+	timeIt("Sieve", func() int { return len(primes.Sieve(n)) })
+	timeIt("SegmentedSieve, 1 worker", func() int {
+		ps, _ := primes.SegmentedSieve(context.Background(), n, 1)
+		return len(ps)
+	})
+	// The segments fit in the CPU cache, so even on one worker the segmented sieve is faster.
+	timeIt(fmt.Sprintf("SegmentedSieve, GOMAXPROCS=%d", runtime.GOMAXPROCS(0)), func() int {
+		ps, _ := primes.SegmentedSieve(context.Background(), n, 0)
+		return len(ps)
+	})
+
+	// Miller-Rabin on each number: no memory needed, but each test costs a few modular exponentiations.
+	const medium = 1_000_000
+	timeIt("Sieve, 1e6", func() int { return len(primes.Sieve(medium)) })
+	timeIt("IsPrime on each number, 1e6", func() int {
+		count := 0
+		for i := uint64(0); i <= medium; i++ {
+			if primes.IsPrime(i) {
+				count++
+			}
+		}
+		return count
+	})
+
+	// The daisy chain passes every number through a channel per prime below it: only a small bound will do.
+	const small = 10_000
+	timeIt("Sieve, 1e4", func() int { return len(primes.Sieve(small)) })
+	timeIt("DaisyChain, 1e4", func() int { return len(primes.DaisyChain(small)) })
+}
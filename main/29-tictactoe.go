@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"golang-demo/tictactoe"
+)
+
+func main() {
+	g := tictactoe.New()
+	g.Play(1, 1)
+	// Invalid moves are rejected, the turn doesn't change.
+	if _, err := g.Play(1, 1); err != nil {
+		fmt.Println(err) // tictactoe: cell occupied: (1, 1) holds X
+	}
+	if _, err := g.Play(3, 0); err != nil {
+		fmt.Println(err) // tictactoe: cell out of the board: (3, 0)
+	}
+	fmt.Println(g.Turn(), "to play")
+
+	// Two players picking random free cells until the game is over.
+	for g.Status() == tictactoe.InProgress {
+		free := g.Free()
+		m := free[rand.Intn(len(free))]
+		g.Play(m.Row, m.Col)
+	}
+	fmt.Println(g)
+	fmt.Println("---")
+
+	// A game can be saved and resumed, with its moves so that they can still be undone.
+	data, _ := json.Marshal(g)
+	fmt.Println(string(data))
+	var resumed tictactoe.Game
+	if err := json.Unmarshal(data, &resumed); err != nil {
+		fmt.Println(err)
+		return
+	}
+	last, _ := resumed.Undo()
+	fmt.Printf("undo %v at (%d, %d)\n", last.Mark, last.Row, last.Col)
+	fmt.Println(&resumed)
+	fmt.Println("---")
+
+	// A game can also start from a position, if it is one a real game can reach.
+	b, _ := tictactoe.ParseBoard("X_X/O_X/__O")
+	from, err := tictactoe.FromBoard(b)
+	fmt.Println(err)
+	fmt.Println(from.Play(0, 1)) // O blocks the top row: in progress <nil>
+	b, _ = tictactoe.ParseBoard("XXX/___/___")
+	_, err = tictactoe.FromBoard(b)
+	fmt.Println(err) // tictactoe: bad board: 3 X for 0 O
+}
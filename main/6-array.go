@@ -3,6 +3,9 @@ package main
 import (
 	"fmt"
 	"strings"
+
+	prime "golang-demo/primes"
+	"golang-demo/tictactoe"
 )
 
 func main() {
@@ -13,7 +16,11 @@ func main() {
 	fmt.Println(a[0], a[1])
 	fmt.Println(a)
 
-	primes := [6]int{2, 3, 5, 7, 11, 13}
+	// An array can be written as a literal, [6]int{2, 3, 5, 7, 11, 13},
+	// or converted from a slice at least as long (since Go 1.20, it panics if the slice is shorter).
+	// Here prime.First(6) computes the first 6 primes (see primes/sieve.go). The package is imported as `prime`
+	// so that the array can keep the name `primes` without hiding it.
+	primes := [6]int(prime.First(6))
 	fmt.Println(primes)
 
 	// A slice is formed by specifying two indices, which includes the first element, but excludes the last one
@@ -97,6 +104,14 @@ func main() {
 		fmt.Printf("%s\n", strings.Join(board[i], " "))
 	}
 
+	// The same moves on a tictactoe.Game (see tictactoe/game.go), which also checks them and keeps the turns.
+	// Its board is an array, tictactoe.Board is [3][3]Mark: a 3x3 board never needs to grow.
+	game := tictactoe.New()
+	for _, cell := range [][2]int{{0, 0}, {2, 2}, {1, 2}, {1, 0}, {0, 2}} {
+		game.Play(cell[0], cell[1])
+	}
+	fmt.Println(game)
+
 	// append works on nil slices.
 	var s6 []int
 	s6 = append(s6, 0)
@@ -0,0 +1,259 @@
+// Package metrics exports keyed counters (like counter.SafeCounter) in the Prometheus text
+// exposition format and as JSON, and serves them over HTTP.
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Collector is anything holding a set of keyed values, e.g. *counter.SafeCounter or *counter.Window.
+type Collector interface {
+	Snapshot() map[string]int
+}
+
+// Kind is the Prometheus type of a metric.
+type Kind string
+
+const (
+	// Counter values only go up (e.g. a SafeCounter that is only incremented).
+	Counter Kind = "counter"
+	// Gauge values go up and down (e.g. a sliding-window count).
+	Gauge Kind = "gauge"
+)
+
+// Labels are the label name/value pairs of one sample.
+type Labels map[string]string
+
+// LabelFunc turns a counter key into the labels of its sample.
+type LabelFunc func(key string) Labels
+
+// KeyLabel puts the whole key into a single label called name.
+func KeyLabel(name string) LabelFunc {
+	return func(key string) Labels {
+		return Labels{name: key}
+	}
+}
+
+// SplitLabels splits the key on sep and assigns the parts to the label names in order,
+// e.g. SplitLabels(" ", "method", "route") turns "GET /users" into {method="GET", route="/users"}.
+// The last name takes the rest of the key, missing parts give empty values.
+func SplitLabels(sep string, names ...string) LabelFunc {
+	return func(key string) Labels {
+		parts := strings.SplitN(key, sep, len(names))
+		labels := make(Labels, len(names))
+		for i, name := range names {
+			if i < len(parts) {
+				labels[name] = parts[i]
+			} else {
+				labels[name] = ""
+			}
+		}
+		return labels
+	}
+}
+
+// Desc describes a registered metric.
+type Desc struct {
+	Name string
+	Help string
+	Kind Kind
+	// Labels derives the labels from the counter key, KeyLabel("key") if nil.
+	Labels LabelFunc
+}
+
+var (
+	metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelName  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type metric struct {
+	Desc
+	c Collector
+}
+
+// Sample is one value of a metric.
+type Sample struct {
+	Labels Labels `json:"labels"`
+	Value  int    `json:"value"`
+}
+
+func (m *metric) samples() ([]Sample, error) {
+	snap := m.c.Snapshot()
+	samples := make([]Sample, 0, len(snap))
+	for k, v := range snap {
+		labels := m.Labels(k)
+		for name := range labels {
+			if !labelName.MatchString(name) {
+				return nil, fmt.Errorf("metrics: %s: invalid label name %q", m.Name, name)
+			}
+		}
+		samples = append(samples, Sample{labels, v})
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return formatLabels(samples[i].Labels) < formatLabels(samples[j].Labels)
+	})
+	return samples, nil
+}
+
+// Registry is a set of named metrics. It is safe to use concurrently.
+type Registry struct {
+	metrics map[string]*metric
+	mux     sync.Mutex
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// Register adds the collector c under the description d. An empty Kind means Counter.
+func (r *Registry) Register(d Desc, c Collector) error {
+	if !metricName.MatchString(d.Name) {
+		return fmt.Errorf("metrics: invalid metric name %q", d.Name)
+	}
+	switch d.Kind {
+	case "":
+		d.Kind = Counter
+	case Counter, Gauge:
+	default:
+		return fmt.Errorf("metrics: %s: invalid kind %q", d.Name, d.Kind)
+	}
+	if d.Labels == nil {
+		d.Labels = KeyLabel("key")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if _, ok := r.metrics[d.Name]; ok {
+		return fmt.Errorf("metrics: %s already registered", d.Name)
+	}
+	r.metrics[d.Name] = &metric{d, c}
+	return nil
+}
+
+// Unregister removes the metric with the given name, it reports whether there was one.
+func (r *Registry) Unregister(name string) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	_, ok := r.metrics[name]
+	delete(r.metrics, name)
+	return ok
+}
+
+// sorted returns the metrics ordered by name.
+func (r *Registry) sorted() []*metric {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	ms := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Name < ms[j].Name })
+	return ms
+}
+
+// WritePrometheus writes all the metrics in the Prometheus text exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	for _, m := range r.sorted() {
+		samples, err := m.samples()
+		if err != nil {
+			return err
+		}
+		if m.Help != "" {
+			fmt.Fprintf(w, "# HELP %s %s\n", m.Name, helpEscaper.Replace(m.Help))
+		}
+		fmt.Fprintf(w, "# TYPE %s %s\n", m.Name, m.Kind)
+		for _, s := range samples {
+			if _, err := fmt.Fprintf(w, "%s%s %d\n", m.Name, formatLabels(s.Labels), s.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	valueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// formatLabels formats labels as `{a="1",b="2"}`, sorted by name.
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, name, valueEscaper.Replace(labels[name]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Family is the JSON view of a metric.
+type Family struct {
+	Name    string   `json:"name"`
+	Help    string   `json:"help,omitempty"`
+	Kind    Kind     `json:"type"`
+	Samples []Sample `json:"samples"`
+}
+
+// Families returns the current state of all the metrics, ordered by name.
+func (r *Registry) Families() ([]Family, error) {
+	ms := r.sorted()
+	families := make([]Family, 0, len(ms))
+	for _, m := range ms {
+		samples, err := m.samples()
+		if err != nil {
+			return nil, err
+		}
+		families = append(families, Family{m.Name, m.Help, m.Kind, samples})
+	}
+	return families, nil
+}
+
+// WriteJSON writes all the metrics as a JSON array of Family.
+func (r *Registry) WriteJSON(w io.Writer) error {
+	families, err := r.Families()
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(families)
+}
+
+// ServeHTTP serves the metrics in the Prometheus format, or as JSON when
+// the request has `?format=json` or accepts application/json.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Render into a buffer first so that an error can still change the status code.
+	var buf bytes.Buffer
+	var err error
+	contentType := "text/plain; version=0.0.4; charset=utf-8"
+	if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+		contentType = "application/json"
+		err = r.WriteJSON(&buf)
+	} else {
+		err = r.WritePrometheus(&buf)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	buf.WriteTo(w)
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// values is a Collector of fixed values.
+type values map[string]int
+
+func (v values) Snapshot() map[string]int {
+	return v
+}
+
+// testRegistry registers metrics covering the format: several kinds and label functions,
+// escaping in HELP and label values, and names to sort.
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	descs := []struct {
+		d Desc
+		c Collector
+	}{
+		{Desc{Name: "http_requests_total", Help: "HTTP requests by method and route.", Labels: SplitLabels(" ", "method", "route")},
+			values{"GET /users": 3, "POST /users": 1, "GET /": 10, "DELETE": 2}},
+		{Desc{Name: "active_sessions", Help: "Sessions seen in the last minute.", Kind: Gauge},
+			values{"zoe": 1, "adam": 4}},
+		{Desc{Name: "escapes", Help: "A help with a \\ backslash\nand a new line.", Labels: KeyLabel("path")},
+			values{`C:\temp`: 1, "two\nlines": 2, `say "hi"`: 3}},
+		{Desc{Name: "no_help:total"}, values{"": -1}},
+		{Desc{Name: "empty", Kind: Gauge}, values{}},
+	}
+	for _, m := range descs {
+		if err := r.Register(m.d, m.c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+// golden compares got to the file testdata/name, or rewrites the file with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: got\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testRegistry(t).WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "registry.prom", buf.Bytes())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testRegistry(t).WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "registry.json", buf.Bytes())
+	var families []Family
+	if err := json.Unmarshal(buf.Bytes(), &families); err != nil || len(families) != 5 {
+		t.Errorf("got %d families, %v", len(families), err)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := testRegistry(t)
+	tests := []struct {
+		url, accept, contentType string
+	}{
+		{"/metrics", "", "text/plain; version=0.0.4; charset=utf-8"},
+		{"/metrics?format=json", "", "application/json"},
+		{"/metrics", "application/json", "application/json"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.url, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("%s, Accept %q: got %d %q, want %q", tt.url, tt.accept, w.Code, w.Header().Get("Content-Type"), tt.contentType)
+		}
+	}
+
+	// A bad label name is an error, reported as such.
+	r.Register(Desc{Name: "bad_labels", Labels: KeyLabel("0bad")}, values{"a": 1})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), `invalid label name "0bad"`) {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	tests := []struct {
+		d  Desc
+		ok bool
+	}{
+		{Desc{Name: "ok"}, true},
+		{Desc{Name: "ok"}, false}, // already registered
+		{Desc{Name: "gauge", Kind: Gauge}, true},
+		{Desc{Name: "counter", Kind: Counter}, true},
+		{Desc{Name: "histogram", Kind: "histogram"}, false},
+		{Desc{Name: "typo", Kind: "Counter"}, false},
+		{Desc{Name: ""}, false},
+		{Desc{Name: "0starts_with_digit"}, false},
+		{Desc{Name: "has-dash"}, false},
+		{Desc{Name: "ns:sub_name"}, true},
+	}
+	for _, tt := range tests {
+		if err := r.Register(tt.d, values{}); (err == nil) != tt.ok {
+			t.Errorf("Register(%q, %q): got %v", tt.d.Name, tt.d.Kind, err)
+		}
+	}
+	if !r.Unregister("ok") || r.Unregister("ok") {
+		t.Error("Unregister: want true then false")
+	}
+	if err := r.Register(Desc{Name: "ok"}, values{}); err != nil {
+		t.Errorf("Register after Unregister: %v", err)
+	}
+}
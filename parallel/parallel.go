@@ -0,0 +1,116 @@
+// Package parallel splits the work on a slice across goroutines, like the `sum` demo does by hand with two halves.
+package parallel
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// checkEvery is how many elements a worker processes between two checks of the context.
+const checkEvery = 1024
+
+// chunks splits [0, n) into at most workers contiguous ranges of nearly equal sizes.
+func chunks(n, workers int) [][2]int {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = max(1, min(workers, n))
+	bounds := make([][2]int, workers)
+	size, extra := n/workers, n%workers
+	start := 0
+	for i := range bounds {
+		end := start + size
+		if i < extra {
+			end++
+		}
+		bounds[i] = [2]int{start, end}
+		start = end
+	}
+	return bounds
+}
+
+// MapReduce maps every element of s with mapper and combines the results with combine, using the given
+// number of workers (GOMAXPROCS if workers <= 0). Each worker folds a contiguous chunk of s starting from
+// identity, then the partial results are combined in the order of the chunks, so combine must be
+// associative (but need not be commutative) and identity must be its neutral element.
+//
+// The first error returned by mapper, or the end of ctx, stops all the workers and is returned.
+func MapReduce[T, R any](ctx context.Context, s []T, workers int, mapper func(T) (R, error), identity R, combine func(R, R) R) (R, error) {
+	if len(s) == 0 {
+		return identity, ctx.Err()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	bounds := chunks(len(s), workers)
+	partials := make([]R, len(bounds))
+	var wg sync.WaitGroup
+	wg.Add(len(bounds))
+	for i, b := range bounds {
+		go func() {
+			defer wg.Done()
+			acc := identity
+			for j, v := range s[b[0]:b[1]] {
+				if j%checkEvery == 0 && ctx.Err() != nil {
+					return
+				}
+				r, err := mapper(v)
+				if err != nil {
+					cancel(err)
+					return
+				}
+				acc = combine(acc, r)
+			}
+			partials[i] = acc
+		}()
+	}
+	wg.Wait()
+	if err := context.Cause(ctx); err != nil {
+		return identity, err
+	}
+
+	result := identity
+	for _, p := range partials {
+		result = combine(result, p)
+	}
+	return result, nil
+}
+
+// Reduce combines the elements of s with combine in parallel, see MapReduce.
+func Reduce[T any](ctx context.Context, s []T, workers int, identity T, combine func(T, T) T) (T, error) {
+	return MapReduce(ctx, s, workers, func(v T) (T, error) { return v, nil }, identity, combine)
+}
+
+// Map returns the results of fn on every element of s, computed by the given number of workers
+// (GOMAXPROCS if workers <= 0). The results are in the order of s.
+// The first error returned by fn, or the end of ctx, stops all the workers and is returned.
+func Map[T, U any](ctx context.Context, s []T, workers int, fn func(T) (U, error)) ([]U, error) {
+	out := make([]U, len(s))
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	for _, b := range chunks(len(s), workers) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := b[0]; i < b[1]; i++ {
+				if (i-b[0])%checkEvery == 0 && ctx.Err() != nil {
+					return
+				}
+				u, err := fn(s[i])
+				if err != nil {
+					cancel(err)
+					return
+				}
+				out[i] = u
+			}
+		}()
+	}
+	wg.Wait()
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
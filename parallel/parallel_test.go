@@ -0,0 +1,231 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChunks(t *testing.T) {
+	tests := []struct {
+		n, workers int
+		want       int // number of chunks
+	}{
+		{0, 4, 1},
+		{0, 0, 1},
+		{1, 4, 1},
+		{3, 8, 3},
+		{10, 3, 3},
+		{10, 10, 10},
+		{1000, 7, 7},
+		{10, 0, min(10, runtime.GOMAXPROCS(0))},
+		{10, -1, min(10, runtime.GOMAXPROCS(0))},
+	}
+	for _, tt := range tests {
+		got := chunks(tt.n, tt.workers)
+		if len(got) != tt.want {
+			t.Errorf("chunks(%d, %d): %d chunks %v, want %d", tt.n, tt.workers, len(got), got, tt.want)
+			continue
+		}
+		// The chunks cover [0, n) in order, with sizes differing by one at most.
+		start, lo, hi := 0, tt.n, 0
+		for _, c := range got {
+			if c[0] != start || c[1] < c[0] {
+				t.Errorf("chunks(%d, %d): %v not contiguous", tt.n, tt.workers, got)
+				break
+			}
+			start = c[1]
+			lo, hi = min(lo, c[1]-c[0]), max(hi, c[1]-c[0])
+		}
+		if start != tt.n || hi-lo > 1 {
+			t.Errorf("chunks(%d, %d): %v", tt.n, tt.workers, got)
+		}
+	}
+}
+
+func TestMapReduceOrder(t *testing.T) {
+	// Concatenation is associative but not commutative: any reordering of the chunks shows.
+	s := make([]int, 5000)
+	var want strings.Builder
+	for i := range s {
+		s[i] = i
+		want.WriteString(strconv.Itoa(i) + ",")
+	}
+	concat := func(a, b string) string { return a + b }
+	format := func(i int) (string, error) { return strconv.Itoa(i) + ",", nil }
+	for _, workers := range []int{-1, 0, 1, 3, 7, 64, 10000} {
+		got, err := MapReduce(context.Background(), s, workers, format, "", concat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want.String() {
+			t.Errorf("workers=%d: results out of order", workers)
+		}
+		strs, err := Map(context.Background(), s, workers, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(strs, "") != want.String() {
+			t.Errorf("Map, workers=%d: results out of order", workers)
+		}
+	}
+
+	if got, err := Reduce(context.Background(), []int{}, 4, 0, func(a, b int) int { return a + b }); got != 0 || err != nil {
+		t.Errorf("Reduce of an empty slice: got %d, %v, want 0, nil", got, err)
+	}
+	if got, err := Map(context.Background(), []int{}, 4, format); len(got) != 0 || err != nil {
+		t.Errorf("Map of an empty slice: got %v, %v", got, err)
+	}
+}
+
+var errOdd = errors.New("odd")
+
+func TestMapReduceError(t *testing.T) {
+	// With one worker the elements are mapped in order: the first failure stops the others.
+	var calls atomic.Int64
+	mapper := func(i int) (int, error) {
+		calls.Add(1)
+		if i%2 == 1 {
+			return 0, fmt.Errorf("%w: %d", errOdd, i)
+		}
+		return i, nil
+	}
+	_, err := MapReduce(context.Background(), []int{0, 2, 3, 5, 6}, 1, mapper, 0, func(a, b int) int { return a + b })
+	if err == nil || err.Error() != "odd: 3" {
+		t.Errorf("got %v, want odd: 3", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("mapper called %d times, want 3", n)
+	}
+
+	// In parallel, the error returned is the first one, even when other workers fail later.
+	errFirst, errLater := errors.New("first"), errors.New("later")
+	var failed atomic.Bool
+	mapper = func(int) (int, error) {
+		if failed.CompareAndSwap(false, true) {
+			return 0, errFirst
+		}
+		time.Sleep(10 * time.Millisecond)
+		return 0, errLater
+	}
+	s := make([]int, 100)
+	if _, err := MapReduce(context.Background(), s, 4, mapper, 0, func(a, b int) int { return a + b }); err != errFirst {
+		t.Errorf("MapReduce: got %v, want %v", err, errFirst)
+	}
+	failed.Store(false)
+	if _, err := Map(context.Background(), s, 4, mapper); err != errFirst {
+		t.Errorf("Map: got %v, want %v", err, errFirst)
+	}
+}
+
+func TestMapReduceContext(t *testing.T) {
+	sum := func(a, b int) int { return a + b }
+	id := func(i int) (int, error) { return i, nil }
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MapReduce(ctx, make([]int, 10), 2, id, 0, sum); !errors.Is(err, context.Canceled) {
+		t.Errorf("MapReduce: got %v, want context.Canceled", err)
+	}
+	if _, err := MapReduce(ctx, nil, 2, id, 0, sum); !errors.Is(err, context.Canceled) {
+		t.Errorf("MapReduce of nil: got %v, want context.Canceled", err)
+	}
+	if _, err := Map(ctx, make([]int, 10), 2, id); !errors.Is(err, context.Canceled) {
+		t.Errorf("Map: got %v, want context.Canceled", err)
+	}
+
+	// Cancelling while the workers run stops them at their next check.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var calls atomic.Int64
+	s := make([]int, 100*checkEvery)
+	_, err := Map(ctx, s, 4, func(i int) (int, error) {
+		if calls.Add(1) == checkEvery {
+			cancel()
+		}
+		return i, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Map cancelled while running: got %v, want context.Canceled", err)
+	}
+	if n := calls.Load(); n >= int64(len(s)) {
+		t.Errorf("mapper called on all the %d elements after cancel", n)
+	}
+}
+
+// benchSlice is mapped with a function costly enough for the workers to pay off.
+var benchSlice = func() []float64 {
+	s := make([]float64, 1<<20)
+	for i := range s {
+		s[i] = float64(i)
+	}
+	return s
+}()
+
+// sink keeps the compiler from dropping the sequential loop as dead code.
+var sink float64
+
+func work(x float64) float64 {
+	for i := 0; i < 20; i++ {
+		x = x*0.5 + 1
+	}
+	return x
+}
+
+func BenchmarkMapReduce(b *testing.B) {
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var sum float64
+			for _, v := range benchSlice {
+				sum += work(v)
+			}
+			sink = sum
+		}
+	})
+	for _, workers := range benchWorkers() {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := MapReduce(context.Background(), benchSlice, workers,
+					func(v float64) (float64, error) { return work(v), nil },
+					0, func(a, b float64) float64 { return a + b })
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			out := make([]float64, len(benchSlice))
+			for j, v := range benchSlice {
+				out[j] = work(v)
+			}
+		}
+	})
+	for _, workers := range benchWorkers() {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Map(context.Background(), benchSlice, workers, func(v float64) (float64, error) { return work(v), nil }); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func benchWorkers() []int {
+	workers := []int{1, 2, 4}
+	if n := runtime.GOMAXPROCS(0); !slices.Contains(workers, n) {
+		workers = append(workers, n)
+	}
+	return workers
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Mode tells whether FanOut keeps the order of its input.
+type Mode int
+
+const (
+	// Unordered emits the results as soon as they are ready.
+	Unordered Mode = iota
+	// Ordered emits the results in the order of the input values, holding back the results that finish early.
+	Ordered
+)
+
+// FanOut applies fn to the values of in with n goroutines in parallel, and returns a channel of the results.
+func FanOut[T, U any](p *Pipeline, in <-chan T, n int, mode Mode, fn func(T) (U, error)) <-chan U {
+	if n < 1 {
+		panic("pipeline: FanOut needs at least one worker")
+	}
+	if mode == Ordered {
+		return fanOutOrdered(p, in, n, fn)
+	}
+	workers := make([]<-chan U, n)
+	for i := range workers {
+		workers[i] = Map(p, in, fn)
+	}
+	return Merge(p, workers...)
+}
+
+// fanOutOrdered starts one goroutine per value, at most n at a time, each with its own result channel.
+// The result channels are queued in input order, and read back in that order.
+func fanOutOrdered[T, U any](p *Pipeline, in <-chan T, n int, fn func(T) (U, error)) <-chan U {
+	type result struct {
+		v   U
+		err error
+	}
+	pending := make(chan chan result, n)
+	p.Go(func(ctx context.Context) error {
+		defer close(pending)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		sem := make(chan struct{}, n)
+		for v := range values(ctx, in) {
+			if !Send(ctx, sem, struct{}{}) {
+				return nil
+			}
+			res := make(chan result, 1)
+			if !Send(ctx, pending, res) {
+				return nil
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				u, err := fn(v)
+				res <- result{u, err}
+				<-sem
+			}()
+		}
+		return nil
+	})
+
+	out := make(chan U)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for res := range values(ctx, pending) {
+			var r result
+			select {
+			case r = <-res:
+			case <-ctx.Done():
+				return nil
+			}
+			if r.err != nil {
+				return r.err
+			}
+			if !Send(ctx, out, r.v) {
+				return nil
+			}
+		}
+		return nil
+	})
+	return out
+}
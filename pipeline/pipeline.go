@@ -0,0 +1,219 @@
+// Package pipeline builds channel pipelines out of typed stages, like the hand-written `sum` and `fib` goroutines,
+// without rewriting the plumbing each time: every stage runs in its own goroutines, stops when the pipeline
+// is cancelled, and the first error of any stage cancels the whole pipeline.
+//
+//	p := pipeline.New(ctx)
+//	nums := pipeline.Source(p, 1, 2, 3, 4)
+//	squares := pipeline.Map(p, nums, func(n int) (int, error) { return n * n, nil })
+//	err := pipeline.Sink(p, squares, func(n int) error { fmt.Println(n); return nil })
+package pipeline
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// Pipeline holds the context and the goroutines shared by the stages of a pipeline.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+}
+
+// New returns a pipeline that stops when ctx is done.
+func New(ctx context.Context) *Pipeline {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Pipeline{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the context of the pipeline, done once the pipeline is stopped or failed.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Fail stops the pipeline because of err, which Wait will return. Only the first failure is kept.
+func (p *Pipeline) Fail(err error) {
+	p.cancel(err)
+}
+
+// Stop stops the pipeline early, Wait then returns context.Canceled.
+func (p *Pipeline) Stop() {
+	p.cancel(nil)
+}
+
+// Wait waits for all the stages to return, and returns the error that stopped the pipeline, if any.
+// Every stage has to finish first: either the outputs are consumed to the end, or the pipeline is stopped.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	err := context.Cause(p.ctx)
+	p.cancel(nil) // release the context's resources
+	return err
+}
+
+// Go runs fn in a goroutine of the pipeline, a non-nil error fails the pipeline.
+// It is how custom stages are written.
+func (p *Pipeline) Go(fn func(ctx context.Context) error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := fn(p.ctx); err != nil {
+			p.Fail(err)
+		}
+	}()
+}
+
+// Send sends v to ch unless the pipeline stops first, it reports whether v was sent.
+func Send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// values iterates over the values received from in until it is closed or ctx is done.
+func values[T any](ctx context.Context, in <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok || !yield(v) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Source returns a channel of the given values.
+func Source[T any](p *Pipeline, values ...T) <-chan T {
+	return Generate(p, func(ctx context.Context, emit func(T) bool) error {
+		for _, v := range values {
+			if !emit(v) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Generate returns a channel of the values passed to emit by fn.
+// emit returns false once the pipeline has stopped, fn should return then.
+func Generate[T any](p *Pipeline, fn func(ctx context.Context, emit func(T) bool) error) <-chan T {
+	out := make(chan T)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		return fn(ctx, func(v T) bool { return Send(ctx, out, v) })
+	})
+	return out
+}
+
+// Map returns a channel of fn applied to every value of in.
+func Map[T, U any](p *Pipeline, in <-chan T, fn func(T) (U, error)) <-chan U {
+	out := make(chan U)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for v := range values(ctx, in) {
+			u, err := fn(v)
+			if err != nil {
+				return err
+			}
+			if !Send(ctx, out, u) {
+				return nil
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// Filter returns a channel of the values of in for which keep returns true.
+func Filter[T any](p *Pipeline, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for v := range values(ctx, in) {
+			if keep(v) && !Send(ctx, out, v) {
+				return nil
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// Batch groups the values of in into slices of size values, the last one may be shorter.
+func Batch[T any](p *Pipeline, in <-chan T, size int) <-chan []T {
+	if size < 1 {
+		panic("pipeline: batch size must be positive")
+	}
+	out := make(chan []T)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		batch := make([]T, 0, size)
+		for v := range values(ctx, in) {
+			batch = append(batch, v)
+			if len(batch) == size {
+				if !Send(ctx, out, batch) {
+					return nil
+				}
+				batch = make([]T, 0, size)
+			}
+		}
+		if len(batch) > 0 {
+			Send(ctx, out, batch)
+		}
+		return nil
+	})
+	return out
+}
+
+// Merge returns a channel of the values of all the inputs, in whatever order they arrive.
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		p.Go(func(ctx context.Context) error {
+			defer wg.Done()
+			for v := range values(ctx, in) {
+				if !Send(ctx, out, v) {
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+	p.Go(func(ctx context.Context) error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// Sink calls fn on every value of in, then waits for the pipeline like Wait.
+// If fn fails, the pipeline is stopped and its error is returned.
+func Sink[T any](p *Pipeline, in <-chan T, fn func(T) error) error {
+	for v := range values(p.ctx, in) {
+		if err := fn(v); err != nil {
+			p.Fail(err)
+			break
+		}
+	}
+	return p.Wait()
+}
+
+// Collect returns all the values of in, then waits for the pipeline like Wait.
+func Collect[T any](p *Pipeline, in <-chan T) ([]T, error) {
+	var collected []T
+	err := Sink(p, in, func(v T) error {
+		collected = append(collected, v)
+		return nil
+	})
+	return collected, err
+}
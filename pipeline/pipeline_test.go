@@ -0,0 +1,169 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang-demo/leaktest"
+)
+
+var errBoom = errors.New("boom")
+
+// naturals emits 0, 1, 2... until the pipeline stops, counting the values emitted in n.
+func naturals(p *Pipeline, n *atomic.Int64) <-chan int {
+	return Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; emit(i); i++ {
+			n.Add(1)
+		}
+		return nil
+	})
+}
+
+func TestStages(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	p := New(context.Background())
+	nums := Source(p, 1, 2, 3, 4, 5, 6, 7)
+	odd := Filter(p, nums, func(n int) bool { return n%2 == 1 })
+	squares := Map(p, odd, func(n int) (int, error) { return n * n, nil })
+	got, err := Collect(p, Batch(p, squares, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]int{{1, 9, 25}, {49}}; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	in := make([]int, 100)
+	for i := range in {
+		in[i] = i
+	}
+	// Random durations: the results are ready in a mixed-up order.
+	delays := make([]time.Duration, len(in))
+	rng := rand.New(rand.NewSource(1))
+	for i := range delays {
+		delays[i] = time.Duration(rng.Intn(200)) * time.Microsecond
+	}
+	slow := func(n int) (int, error) {
+		time.Sleep(delays[n])
+		return -n, nil
+	}
+	want := make([]int, len(in))
+	for i := range want {
+		want[i] = -i
+	}
+	for _, mode := range []Mode{Ordered, Unordered} {
+		for _, workers := range []int{1, 3, 16} {
+			p := New(context.Background())
+			got, err := Collect(p, FanOut(p, Source(p, in...), workers, mode, slow))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode == Unordered {
+				slices.Sort(got)
+				slices.Reverse(got)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("mode %d, %d workers: got %v", mode, workers, got)
+			}
+		}
+	}
+}
+
+func TestFirstErrorCancels(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	stages := map[string]func(*Pipeline, <-chan int) <-chan int{
+		"map": func(p *Pipeline, in <-chan int) <-chan int {
+			return Map(p, in, func(n int) (int, error) {
+				if n == 10 {
+					return 0, errBoom
+				}
+				return n, nil
+			})
+		},
+		"ordered fan-out": func(p *Pipeline, in <-chan int) <-chan int {
+			return FanOut(p, in, 4, Ordered, func(n int) (int, error) {
+				if n == 10 {
+					return 0, errBoom
+				}
+				return n, nil
+			})
+		},
+	}
+	for name, stage := range stages {
+		p := New(context.Background())
+		var emitted atomic.Int64
+		// The source never ends by itself: only the cancellation stops it.
+		out := stage(p, naturals(p, &emitted))
+		var got []int
+		err := Sink(p, out, func(n int) error {
+			got = append(got, n)
+			return nil
+		})
+		if err != errBoom {
+			t.Errorf("%s: got %v, want boom", name, err)
+		}
+		if p.Context().Err() == nil {
+			t.Errorf("%s: context not cancelled", name)
+		}
+		// Upstream stopped soon after the error: only the values already in flight were emitted.
+		if n := emitted.Load(); n > 20 {
+			t.Errorf("%s: %d values emitted", name, n)
+		}
+		if len(got) > 10 || (len(got) > 0 && got[len(got)-1] >= 10) {
+			t.Errorf("%s: got %v", name, got)
+		}
+	}
+
+	// An error of the sink stops the pipeline the same way.
+	p := New(context.Background())
+	var emitted atomic.Int64
+	err := Sink(p, naturals(p, &emitted), func(n int) error {
+		if n == 3 {
+			return errBoom
+		}
+		return nil
+	})
+	if err != errBoom {
+		t.Errorf("sink: got %v, want boom", err)
+	}
+}
+
+func TestStop(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	for _, mode := range []Mode{Unordered, Ordered} {
+		p := New(context.Background())
+		var emitted atomic.Int64
+		evens := Filter(p, naturals(p, &emitted), func(n int) bool { return n%2 == 0 })
+		out := FanOut(p, evens, 4, mode, func(n int) (int, error) { return n / 2, nil })
+		for range 5 {
+			<-out
+		}
+		// Stop with every stage blocked on a send: they all return, none is left behind.
+		p.Stop()
+		if err := p.Wait(); err != context.Canceled {
+			t.Errorf("mode %d: Wait got %v, want Canceled", mode, err)
+		}
+		if _, ok := <-out; ok {
+			t.Errorf("mode %d: output still open after Wait", mode)
+		}
+	}
+
+	// A parent context done stops the pipeline too.
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx)
+	var emitted atomic.Int64
+	out := naturals(p, &emitted)
+	<-out
+	cancel()
+	if err := p.Wait(); err != context.Canceled {
+		t.Errorf("parent cancelled: Wait got %v, want Canceled", err)
+	}
+}
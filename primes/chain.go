@@ -0,0 +1,47 @@
+package primes
+
+// DaisyChain returns the primes up to n included, with the concurrent prime sieve of the Go documentation:
+// a goroutine generates 2, 3, 4..., and each prime found adds a goroutine to the chain, filtering out its multiples.
+// Whatever comes out of the end of the chain is the next prime.
+//
+//	generate -> filter 2 -> filter 3 -> filter 5 -> ...
+//
+// It is a nice picture of goroutines and channels, but far slower than Sieve: every number goes
+// through a channel send per filter, and there is one goroutine per prime found.
+func DaisyChain(n int) []int {
+	if n < 2 {
+		return nil
+	}
+	ch := make(chan int)
+	go generate(n, ch)
+	var ps []int
+	for {
+		p, ok := <-ch
+		if !ok {
+			// The generator is done and each filter has closed its output: no goroutine is left.
+			return ps
+		}
+		ps = append(ps, p)
+		next := make(chan int)
+		go filter(ch, next, p)
+		ch = next
+	}
+}
+
+// generate sends 2, 3, ..., n to ch, then closes it.
+func generate(n int, ch chan<- int) {
+	for i := 2; i <= n; i++ {
+		ch <- i
+	}
+	close(ch)
+}
+
+// filter copies the values from in to out, except the multiples of p. It closes out once in is closed.
+func filter(in <-chan int, out chan<- int, p int) {
+	for i := range in {
+		if i%p != 0 {
+			out <- i
+		}
+	}
+	close(out)
+}
@@ -0,0 +1,149 @@
+package primes
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// mulMod returns a*b mod m without overflow.
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+// addMod returns a+b mod m for a, b < m, without overflow.
+func addMod(a, b, m uint64) uint64 {
+	s, carry := bits.Add64(a, b, 0)
+	if carry != 0 || s >= m {
+		s -= m
+	}
+	return s
+}
+
+// powMod returns a^e mod m.
+func powMod(a, e, m uint64) uint64 {
+	r := uint64(1)
+	a %= m
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = mulMod(r, a, m)
+		}
+		a = mulMod(a, a, m)
+	}
+	return r
+}
+
+// millerRabinBases are enough for the Miller-Rabin test to be exact for every 64-bit number.
+var millerRabinBases = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// IsPrime reports whether n is prime, with the Miller-Rabin test.
+// Write n-1 = d·2^s with d odd: for a prime n, and any base a, either a^d ≡ 1 or a^(d·2^r) ≡ -1 (mod n)
+// for some r < s. A base for which this fails proves n composite; passing the 12 bases above proves
+// n prime for all n < 2^64, so the test is deterministic here, no randomness involved.
+func IsPrime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for _, p := range millerRabinBases {
+		if n%p == 0 {
+			return n == p
+		}
+	}
+	d, s := n-1, 0
+	for d%2 == 0 {
+		d /= 2
+		s++
+	}
+	for _, a := range millerRabinBases {
+		x := powMod(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for r := 1; r < s; r++ {
+			x = mulMod(x, x, n)
+			if x == n-1 {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+// Factor returns the prime factors of n in increasing order, repeated as many times as they divide n:
+// Factor(360) is [2 2 2 3 3 5]. Factor(0) and Factor(1) are empty.
+// Small factors are found by trial division, large ones with Pollard's rho algorithm.
+func Factor(n uint64) []uint64 {
+	if n < 2 {
+		return nil
+	}
+	var fs []uint64
+	for _, p := range []uint64{2, 3, 5} {
+		for n%p == 0 {
+			fs = append(fs, p)
+			n /= p
+		}
+	}
+	// Trial division by the numbers not multiple of 2, 3 or 5, up to a small bound.
+	wheel := []uint64{4, 2, 4, 2, 4, 6, 2, 6}
+	for p, i := uint64(7), 0; p <= 1<<12 && p*p <= n; p, i = p+wheel[i], (i+1)%len(wheel) {
+		for n%p == 0 {
+			fs = append(fs, p)
+			n /= p
+		}
+	}
+	if n > 1 {
+		fs = append(fs, factorLarge(n)...)
+	}
+	slices.Sort(fs)
+	return fs
+}
+
+// factorLarge returns the prime factors of n, which has no small factors.
+func factorLarge(n uint64) []uint64 {
+	if n == 1 {
+		return nil
+	}
+	if IsPrime(n) {
+		return []uint64{n}
+	}
+	d := rho(n)
+	return append(factorLarge(d), factorLarge(n/d)...)
+}
+
+// rho returns a non-trivial divisor of the composite n, with Pollard's rho algorithm:
+// the sequence x ← x²+c mod n eventually cycles modulo any prime p dividing n, long before it does modulo n,
+// and the cycle shows up as gcd(|x-y|, n) > 1 for x and y at different speeds in the sequence (Floyd).
+func rho(n uint64) uint64 {
+	for c := uint64(1); ; c++ {
+		f := func(x uint64) uint64 { return addMod(mulMod(x, x, n), c, n) }
+		x, y, d := uint64(2), uint64(2), uint64(1)
+		for d == 1 {
+			x = f(x)
+			y = f(f(y))
+			d = gcd(diff(x, y), n)
+		}
+		if d != n {
+			return d
+		}
+		// The cycle closed modulo n too, try another sequence.
+	}
+}
+
+func diff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,191 @@
+package primes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// byIsPrime returns the primes up to n found one by one with IsPrime, to check the sieves against.
+func byIsPrime(n int) []int {
+	var ps []int
+	for i := 0; i <= n; i++ {
+		if IsPrime(uint64(i)) {
+			ps = append(ps, i)
+		}
+	}
+	return ps
+}
+
+func TestSieve(t *testing.T) {
+	for _, n := range []int{-1, 0, 1, 2, 3, 4, 10, 97, 100, 10000} {
+		if got, want := Sieve(n), byIsPrime(n); !slices.Equal(got, want) {
+			t.Errorf("Sieve(%d): got %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestSegmentedSieve(t *testing.T) {
+	// Bounds around the segment edges, where an off-by-one would drop or repeat a number.
+	for _, n := range []int{0, 1, 2, 1000, segmentSize, segmentSize + 1, 2*segmentSize + 1, 5*segmentSize + 17} {
+		want := Sieve(n)
+		for _, workers := range []int{0, 1, 3, 16} {
+			got, err := SegmentedSieve(context.Background(), n, workers)
+			if err != nil {
+				t.Fatalf("SegmentedSieve(%d, %d): %v", n, workers, err)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("SegmentedSieve(%d, %d): %d primes, want %d", n, workers, len(got), len(want))
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := SegmentedSieve(ctx, 10*segmentSize, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: got %v, want context.Canceled", err)
+	}
+}
+
+func TestDaisyChain(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 30, 2000} {
+		if got, want := DaisyChain(n), Sieve(n); !slices.Equal(got, want) {
+			t.Errorf("DaisyChain(%d): got %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestFirst(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 6, 7, 1000} {
+		got := First(n)
+		if len(got) != n {
+			t.Fatalf("First(%d): %d primes", n, len(got))
+		}
+		if want := Sieve(8000); !slices.Equal(got, want[:n]) {
+			t.Errorf("First(%d): got %v, want %v", n, got, want[:n])
+		}
+	}
+	if got := First(-1); got != nil {
+		t.Errorf("First(-1): got %v", got)
+	}
+}
+
+func TestIsPrime(t *testing.T) {
+	primes := []uint64{
+		2, 3, 5, 37, 41, 7919,
+		4294967291,           // the largest prime below 2^32
+		2305843009213693951,  // 2^61 - 1, a Mersenne prime
+		18446744073709551557, // the largest prime below 2^64
+	}
+	for _, n := range primes {
+		if !IsPrime(n) {
+			t.Errorf("IsPrime(%d): got false", n)
+		}
+	}
+	composites := []uint64{0, 1, 4, 9, 1 << 32, 4294967291 * 3, 1<<64 - 1}
+	// Carmichael numbers: a^(n-1) ≡ 1 (mod n) for every a coprime to n, they fool the Fermat test.
+	carmichael := []uint64{561, 1105, 1729, 2465, 2821, 6601, 8911, 10585, 15841, 29341, 41041, 62745, 63973}
+	// Strong pseudoprimes, the smallest passing Miller-Rabin for all the prime bases up to some bound:
+	// 2047 fools base 2 alone, 3825123056546413051 every base up to 31.
+	strong := []uint64{
+		2047, 1373653, 25326001, 3215031751, 2152302898747, 3474749660383,
+		341550071728321, 3825123056546413051,
+	}
+	for _, list := range [][]uint64{composites, carmichael, strong} {
+		for _, n := range list {
+			if IsPrime(n) {
+				t.Errorf("IsPrime(%d): got true", n)
+			}
+		}
+	}
+}
+
+func TestFactor(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want []uint64
+	}{
+		{0, nil},
+		{1, nil},
+		{2, []uint64{2}},
+		{360, []uint64{2, 2, 2, 3, 3, 5}},
+		{561, []uint64{3, 11, 17}},
+		{4294967291, []uint64{4294967291}},
+		{4294967279 * 4294967291, []uint64{4294967279, 4294967291}},
+		{1<<64 - 1, []uint64{3, 5, 17, 257, 641, 65537, 6700417}},
+		{3825123056546413051, []uint64{149491, 747451, 34233211}},
+	}
+	for _, tt := range tests {
+		if got := Factor(tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("Factor(%d): got %v, want %v", tt.n, got, tt.want)
+		}
+	}
+
+	// Random numbers, small and large: the factors are prime, sorted, and multiply back to n.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		n := rng.Uint64() >> rng.Intn(64)
+		fs := Factor(n)
+		p := uint64(1)
+		for _, f := range fs {
+			if !IsPrime(f) {
+				t.Errorf("Factor(%d): %d is not prime", n, f)
+			}
+			p *= f
+		}
+		if n >= 2 && p != n {
+			t.Errorf("Factor(%d) = %v multiply to %d", n, fs, p)
+		}
+		if !slices.IsSorted(fs) {
+			t.Errorf("Factor(%d) = %v not sorted", n, fs)
+		}
+	}
+}
+
+const benchN = 1_000_000
+
+func BenchmarkSieve(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Sieve(benchN)
+	}
+}
+
+func BenchmarkSegmentedSieve(b *testing.B) {
+	workers := []int{1, 2, 4}
+	if n := runtime.GOMAXPROCS(0); !slices.Contains(workers, n) {
+		workers = append(workers, n)
+	}
+	for _, workers := range workers {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := SegmentedSieve(context.Background(), benchN, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDaisyChain uses a much smaller bound: with one goroutine per prime the chain is quadratic.
+func BenchmarkDaisyChain(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		DaisyChain(10_000)
+	}
+}
+
+func BenchmarkIsPrime(b *testing.B) {
+	b.Run("small", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			IsPrime(uint64(i))
+		}
+	})
+	b.Run("large", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			IsPrime(18446744073709551557 - 2*uint64(i%1000))
+		}
+	})
+}
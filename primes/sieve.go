@@ -0,0 +1,97 @@
+// Package primes computes prime numbers in several ways: sieves listing all the primes up to a bound,
+// sequential, parallel or as a chain of goroutines, and tests and factorizations of single numbers.
+package primes
+
+import (
+	"context"
+	"math"
+
+	"golang-demo/parallel"
+)
+
+// Sieve returns the primes up to n included, with the Sieve of Eratosthenes:
+// the multiples of each prime are crossed out, the numbers left are prime.
+func Sieve(n int) []int {
+	if n < 2 {
+		return nil
+	}
+	composite := make([]bool, n+1)
+	var ps []int
+	for i := 2; i <= n; i++ {
+		if composite[i] {
+			continue
+		}
+		ps = append(ps, i)
+		// The smaller multiples of i have been crossed out by smaller primes already.
+		for j := i * i; j <= n; j += i {
+			composite[j] = true
+		}
+	}
+	return ps
+}
+
+// segmentSize is how many numbers a segment of the segmented sieve covers,
+// small enough for its flags to stay in the CPU cache.
+const segmentSize = 1 << 16
+
+// SegmentedSieve returns the primes up to n included, like Sieve, but splits [2, n] into segments
+// sieved in parallel by the given number of workers (GOMAXPROCS if workers <= 0).
+// Crossing out a segment only needs the primes up to √n, found first with Sieve.
+// It returns ctx.Err() if ctx is done first.
+func SegmentedSieve(ctx context.Context, n, workers int) ([]int, error) {
+	if n < 2 {
+		return nil, nil
+	}
+	base := Sieve(int(math.Sqrt(float64(n))))
+	var segments [][2]int
+	for lo := 2; lo <= n; lo += segmentSize {
+		segments = append(segments, [2]int{lo, min(lo+segmentSize-1, n)})
+	}
+	found, err := parallel.Map(ctx, segments, workers, func(seg [2]int) ([]int, error) {
+		return sieveSegment(seg[0], seg[1], base), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ps []int
+	for _, f := range found {
+		ps = append(ps, f...)
+	}
+	return ps, nil
+}
+
+// sieveSegment returns the primes in [lo, hi], base holding the primes up to √hi at least.
+func sieveSegment(lo, hi int, base []int) []int {
+	composite := make([]bool, hi-lo+1)
+	for _, p := range base {
+		if p*p > hi {
+			break
+		}
+		// First multiple of p in the segment worth crossing out.
+		start := max(p*p, (lo+p-1)/p*p)
+		for j := start; j <= hi; j += p {
+			composite[j-lo] = true
+		}
+	}
+	var ps []int
+	for i, c := range composite {
+		if !c {
+			ps = append(ps, lo+i)
+		}
+	}
+	return ps
+}
+
+// First returns the first n primes.
+func First(n int) []int {
+	if n <= 0 {
+		return nil
+	}
+	// The n-th prime is below n(ln n + ln ln n) for n >= 6.
+	bound := 13
+	if n >= 6 {
+		f := float64(n)
+		bound = int(f * (math.Log(f) + math.Log(math.Log(f))))
+	}
+	return Sieve(bound)[:n]
+}
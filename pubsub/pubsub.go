@@ -0,0 +1,303 @@
+// Package pubsub is an in-process publish/subscribe broker built on channels.
+// Publishers send values to topics such as "orders.created", subscribers receive them on buffered channels
+// for the topics matching their pattern, so neither side needs to know about the other.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Message is a value published to a topic.
+type Message[T any] struct {
+	Topic string
+	Value T
+}
+
+// Policy tells what Publish does when a subscriber's buffer is full.
+type Policy int
+
+const (
+	// Block waits until the subscriber has room, slowing the publisher down to the subscriber's pace.
+	Block Policy = iota
+	// DropOldest discards the oldest buffered message to make room for the new one.
+	DropOldest
+	// DropNewest discards the new message.
+	DropNewest
+)
+
+// ErrClosed is returned when subscribing to or publishing on a closed broker.
+var ErrClosed = errors.New("pubsub: broker closed")
+
+// Stats are delivery counters.
+type Stats struct {
+	Published   int64 // messages published (broker only)
+	Delivered   int64 // messages put in a subscriber's buffer
+	Dropped     int64 // messages discarded because a buffer was full
+	Subscribers int   // current subscriptions (broker only)
+	Pending     int   // messages buffered, not received yet (subscription only)
+}
+
+// Broker routes published messages to the matching subscriptions. It is safe to use concurrently.
+type Broker[T any] struct {
+	mux       sync.RWMutex
+	subs      map[*Subscription[T]]struct{}
+	closed    bool
+	published atomic.Int64
+	delivered atomic.Int64
+	dropped   atomic.Int64
+}
+
+// NewBroker returns a broker without subscriptions.
+func NewBroker[T any]() *Broker[T] {
+	return &Broker[T]{subs: make(map[*Subscription[T]]struct{})}
+}
+
+// Subscription receives the messages of the topics matching its pattern.
+type Subscription[T any] struct {
+	b       *Broker[T]
+	pattern []string
+	policy  Policy
+	ch      chan Message[T]
+	done    chan struct{} // closed by Unsubscribe, wakes up blocked publishers
+	once    sync.Once
+
+	// Senders hold sending for reading while they send, Unsubscribe takes it for writing before closing ch.
+	sending sync.RWMutex
+	// dropMux makes "drop the oldest then send" atomic among publishers.
+	dropMux sync.Mutex
+
+	delivered atomic.Int64
+	dropped   atomic.Int64
+}
+
+// Subscribe returns a subscription to the topics matching pattern, with a buffer of the given size
+// (at least 1 with the drop policies).
+// Topics are dot-separated words. In a pattern, `*` matches exactly one word and a final `>` matches
+// one or more words: "orders.*" matches "orders.created" but not "orders.eu.created", "orders.>" matches both.
+func (b *Broker[T]) Subscribe(pattern string, buffer int, policy Policy) (*Subscription[T], error) {
+	words, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if buffer < 0 {
+		buffer = 0
+	}
+	if policy != Block && buffer == 0 {
+		// Dropping needs a buffer to drop from.
+		buffer = 1
+	}
+	s := &Subscription[T]{
+		b:       b,
+		pattern: words,
+		policy:  policy,
+		ch:      make(chan Message[T], buffer),
+		done:    make(chan struct{}),
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	b.subs[s] = struct{}{}
+	return s, nil
+}
+
+func parsePattern(pattern string) ([]string, error) {
+	words := strings.Split(pattern, ".")
+	for i, w := range words {
+		if w == "" {
+			return nil, errors.New("pubsub: empty word in pattern " + pattern)
+		}
+		if w == ">" && i != len(words)-1 {
+			return nil, errors.New("pubsub: `>` must end the pattern " + pattern)
+		}
+	}
+	return words, nil
+}
+
+// parseTopic splits topic into words, it rejects empty words and the wildcards, which only patterns may use.
+func parseTopic(topic string) ([]string, error) {
+	words := strings.Split(topic, ".")
+	for _, w := range words {
+		if w == "" {
+			return nil, errors.New("pubsub: empty word in topic " + topic)
+		}
+		if w == "*" || w == ">" {
+			return nil, errors.New("pubsub: wildcard `" + w + "` in topic " + topic)
+		}
+	}
+	return words, nil
+}
+
+// match reports whether the topic words match the pattern words.
+func match(pattern, topic []string) bool {
+	for i, p := range pattern {
+		if p == ">" {
+			return len(topic) > i
+		}
+		if i >= len(topic) || (p != "*" && p != topic[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(topic)
+}
+
+// Publish sends v to every subscription matching topic and returns how many received it.
+// It may block on the subscriptions with the Block policy, see PublishContext to bound the wait.
+func (b *Broker[T]) Publish(topic string, v T) (int, error) {
+	return b.PublishContext(context.Background(), topic, v)
+}
+
+// PublishContext is like Publish, but gives up waiting for blocked subscribers when ctx is done,
+// in which case it returns ctx.Err() along with the number of subscriptions that did receive the message.
+// The topic must be made of non-empty words without wildcards.
+func (b *Broker[T]) PublishContext(ctx context.Context, topic string, v T) (int, error) {
+	words, err := parseTopic(topic)
+	if err != nil {
+		return 0, err
+	}
+	b.mux.RLock()
+	if b.closed {
+		b.mux.RUnlock()
+		return 0, ErrClosed
+	}
+	var targets []*Subscription[T]
+	for s := range b.subs {
+		if match(s.pattern, words) {
+			targets = append(targets, s)
+		}
+	}
+	b.mux.RUnlock()
+
+	b.published.Add(1)
+	m := Message[T]{Topic: topic, Value: v}
+	n := 0
+	var firstErr error
+	for _, s := range targets {
+		// Keep going after an error: the subscribers that have room still get the message.
+		ok, err := s.send(ctx, m)
+		if ok {
+			n++
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return n, firstErr
+}
+
+// send delivers m according to the policy, it reports whether m was buffered.
+func (s *Subscription[T]) send(ctx context.Context, m Message[T]) (bool, error) {
+	s.sending.RLock()
+	defer s.sending.RUnlock()
+	select {
+	case <-s.done:
+		return false, nil
+	default:
+	}
+
+	switch s.policy {
+	case DropNewest:
+		select {
+		case s.ch <- m:
+		default:
+			s.drop()
+			return false, nil
+		}
+	case DropOldest:
+		s.dropMux.Lock()
+		defer s.dropMux.Unlock()
+		for sent := false; !sent; {
+			select {
+			case s.ch <- m:
+				sent = true
+			default:
+				select {
+				case <-s.ch:
+					s.drop()
+				default:
+				}
+			}
+		}
+	default:
+		// Try without waiting first: once ctx is done, a subscriber with room must still get m.
+		select {
+		case s.ch <- m:
+		default:
+			select {
+			case s.ch <- m:
+			case <-s.done:
+				return false, nil
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+		}
+	}
+	s.delivered.Add(1)
+	s.b.delivered.Add(1)
+	return true, nil
+}
+
+func (s *Subscription[T]) drop() {
+	s.dropped.Add(1)
+	s.b.dropped.Add(1)
+}
+
+// C returns the channel on which the messages arrive. It is closed by Unsubscribe.
+func (s *Subscription[T]) C() <-chan Message[T] {
+	return s.ch
+}
+
+// Unsubscribe stops the deliveries and closes the channel, after the messages already buffered.
+func (s *Subscription[T]) Unsubscribe() {
+	s.once.Do(func() {
+		s.b.mux.Lock()
+		delete(s.b.subs, s)
+		s.b.mux.Unlock()
+		close(s.done)
+		// Wait for the publishers in the middle of a send before closing the channel.
+		s.sending.Lock()
+		close(s.ch)
+		s.sending.Unlock()
+	})
+}
+
+// Stats returns the delivery counters of the subscription.
+func (s *Subscription[T]) Stats() Stats {
+	return Stats{
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+		Pending:   len(s.ch),
+	}
+}
+
+// Stats returns the delivery counters of the broker, summed over all the subscriptions.
+func (b *Broker[T]) Stats() Stats {
+	b.mux.RLock()
+	n := len(b.subs)
+	b.mux.RUnlock()
+	return Stats{
+		Published:   b.published.Load(),
+		Delivered:   b.delivered.Load(),
+		Dropped:     b.dropped.Load(),
+		Subscribers: n,
+	}
+}
+
+// Close unsubscribes all the subscriptions, later calls to Publish and Subscribe return ErrClosed.
+func (b *Broker[T]) Close() {
+	b.mux.Lock()
+	b.closed = true
+	subs := make([]*Subscription[T], 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mux.Unlock()
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+}
@@ -0,0 +1,241 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-demo/leaktest"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"orders.created", "orders.created", true},
+		{"orders.created", "orders.deleted", false},
+		{"orders.created", "orders", false},
+		{"orders", "orders.created", false},
+		{"orders.*", "orders.created", true},
+		{"orders.*", "orders.eu.created", false},
+		{"orders.*", "orders", false},
+		{"*.created", "orders.created", true},
+		{"*.*", "orders.eu", true},
+		{"orders.>", "orders.created", true},
+		{"orders.>", "orders.eu.created", true},
+		{"orders.>", "orders", false},
+		{">", "orders", true},
+		{">", "orders.eu.created", true},
+		{"*.eu.>", "orders.eu.created", true},
+		{"*.eu.>", "orders.us.created", false},
+	}
+	for _, tt := range tests {
+		pattern, err := parsePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("parsePattern(%q): %v", tt.pattern, err)
+		}
+		topic, err := parseTopic(tt.topic)
+		if err != nil {
+			t.Fatalf("parseTopic(%q): %v", tt.topic, err)
+		}
+		if got := match(pattern, topic); got != tt.want {
+			t.Errorf("%q matching %q: got %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestBadNames(t *testing.T) {
+	b := NewBroker[int]()
+	sub, _ := b.Subscribe(">", 10, Block)
+	for _, pattern := range []string{"", ".", "orders.", ".orders", "orders..created", ">.orders", "a.>.b"} {
+		if _, err := b.Subscribe(pattern, 1, Block); err == nil {
+			t.Errorf("Subscribe(%q): got no error", pattern)
+		}
+	}
+	for _, topic := range []string{"", ".", "orders.", "orders..created", "orders.*", "orders.>", "*", ">"} {
+		if n, err := b.Publish(topic, 1); err == nil || n != 0 {
+			t.Errorf("Publish(%q): got %d, %v, want an error", topic, n, err)
+		}
+	}
+	// Nothing reached the subscriber matching everything.
+	if st := sub.Stats(); st.Pending != 0 {
+		t.Errorf("%d messages delivered", st.Pending)
+	}
+	if st := b.Stats(); st.Published != 0 {
+		t.Errorf("Published: got %d, want 0", st.Published)
+	}
+}
+
+// received drains the messages buffered in s.
+func received(s *Subscription[int]) []int {
+	var vs []int
+	for {
+		select {
+		case m := <-s.C():
+			vs = append(vs, m.Value)
+		default:
+			return vs
+		}
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		policy    Policy
+		buffer    int
+		want      []int
+		delivered int64
+		dropped   int64
+	}{
+		{DropNewest, 2, []int{1, 2}, 2, 3},
+		{DropOldest, 2, []int{4, 5}, 5, 3},
+		// The drop policies get a buffer of 1 at least.
+		{DropNewest, 0, []int{1}, 1, 4},
+		{DropOldest, 0, []int{5}, 5, 4},
+	}
+	for _, tt := range tests {
+		b := NewBroker[int]()
+		s, err := b.Subscribe("a", tt.buffer, tt.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reached := 0
+		for v := 1; v <= 5; v++ {
+			n, err := b.Publish("a", v)
+			if err != nil {
+				t.Fatalf("policy %d: Publish: %v", tt.policy, err)
+			}
+			reached += n
+		}
+		// Publish counts the messages buffered, even those dropped later on.
+		if int64(reached) != tt.delivered {
+			t.Errorf("policy %d, buffer %d: Publish reached %d subscribers, want %d", tt.policy, tt.buffer, reached, tt.delivered)
+		}
+		if got := received(s); !slices.Equal(got, tt.want) {
+			t.Errorf("policy %d, buffer %d: got %v, want %v", tt.policy, tt.buffer, got, tt.want)
+		}
+		if st := s.Stats(); st.Delivered != tt.delivered || st.Dropped != tt.dropped {
+			t.Errorf("policy %d, buffer %d: got %+v, want %d delivered, %d dropped", tt.policy, tt.buffer, st, tt.delivered, tt.dropped)
+		}
+		if st := b.Stats(); st.Published != 5 || st.Delivered != tt.delivered || st.Dropped != tt.dropped || st.Subscribers != 1 {
+			t.Errorf("policy %d, buffer %d: broker stats %+v", tt.policy, tt.buffer, st)
+		}
+	}
+}
+
+func TestBlockContext(t *testing.T) {
+	b := NewBroker[int]()
+	full, _ := b.Subscribe("a", 1, Block)
+	free, _ := b.Subscribe("a", 2, Block)
+	b.Publish("a", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// The full subscriber times out, the other one still gets the message.
+	n, err := b.PublishContext(ctx, "a", 2)
+	if n != 1 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %d, %v, want 1, DeadlineExceeded", n, err)
+	}
+	if got := received(full); !slices.Equal(got, []int{1}) {
+		t.Errorf("full subscriber: got %v", got)
+	}
+	if got := received(free); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("free subscriber: got %v", got)
+	}
+}
+
+func TestUnsubscribeBlockedPublisher(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	b := NewBroker[int]()
+	s, _ := b.Subscribe("a", 0, Block)
+	done := make(chan error)
+	go func() {
+		_, err := b.Publish("a", 1)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond) // let the publisher block on the unbuffered channel
+	s.Unsubscribe()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Publish: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the publisher is still blocked")
+	}
+	if _, ok := <-s.C(); ok {
+		t.Error("message received after Unsubscribe")
+	}
+	s.Unsubscribe() // no-op
+	if st := b.Stats(); st.Subscribers != 0 || st.Delivered != 0 {
+		t.Errorf("got %+v", st)
+	}
+}
+
+// Publishers, Unsubscribe and Close all at once: nobody sends on a closed channel or stays blocked.
+func TestCloseRace(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	for i := 0; i < 50; i++ {
+		b := NewBroker[int]()
+		var subs []*Subscription[int]
+		for _, p := range []Policy{Block, Block, DropOldest, DropNewest} {
+			s, _ := b.Subscribe("a.>", 1, p)
+			subs = append(subs, s)
+		}
+		var wg sync.WaitGroup
+		for p := 0; p < 4; p++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for v := 0; ; v++ {
+					if _, err := b.Publish("a.b", v); err != nil {
+						if err != ErrClosed {
+							t.Errorf("Publish: %v", err)
+						}
+						return
+					}
+				}
+			}()
+		}
+		subs[0].Unsubscribe()
+		b.Close()
+		wg.Wait()
+		for _, s := range subs {
+			for range s.C() {
+			}
+		}
+	}
+
+	b := NewBroker[int]()
+	b.Close()
+	if _, err := b.Subscribe("a", 1, Block); err != ErrClosed {
+		t.Errorf("Subscribe after Close: got %v, want ErrClosed", err)
+	}
+	if _, err := b.Publish("a", 1); err != ErrClosed {
+		t.Errorf("Publish after Close: got %v, want ErrClosed", err)
+	}
+}
+
+func TestMessageTopic(t *testing.T) {
+	b := NewBroker[string]()
+	s, _ := b.Subscribe("orders.*", 10, Block)
+	for _, topic := range []string{"orders.created", "users.created", "orders.eu.created", "orders.paid"} {
+		b.Publish(topic, strings.ToUpper(topic))
+	}
+	s.Unsubscribe()
+	var got []string
+	for m := range s.C() {
+		if m.Value != strings.ToUpper(m.Topic) {
+			t.Errorf("topic %q carried %q", m.Topic, m.Value)
+		}
+		got = append(got, m.Topic)
+	}
+	if want := []string{"orders.created", "orders.paid"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,262 @@
+// Package queue provides a bounded FIFO queue that works like a buffered channel, `make(chan T, n)`,
+// but can tell how full it is, who is waiting on it and for how long.
+package queue
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClosed is returned when putting into a closed queue, or getting from a closed queue that is empty.
+	ErrClosed = errors.New("queue: closed")
+	// ErrFull is returned by TryPut when the queue is full.
+	ErrFull = errors.New("queue: full")
+	// ErrEmpty is returned by TryGet when the queue is empty.
+	ErrEmpty = errors.New("queue: empty")
+)
+
+// Queue is a bounded FIFO queue backed by a ring buffer. It is safe to use concurrently.
+type Queue[T any] struct {
+	mux     sync.Mutex
+	buf     []T
+	head    int // index of the first value
+	n       int // number of values
+	closed  bool
+	changed chan struct{} // closed and replaced whenever values are put, got, or the queue is closed
+
+	stats Stats
+}
+
+// Stats describe the use of a queue.
+type Stats struct {
+	Len         int   // values in the queue now
+	Cap         int   // capacity
+	HighWater   int   // most values ever in the queue at once
+	Puts        int64 // values put
+	Gets        int64 // values got
+	Drops       int64 // values TryPut rejected because the queue was full
+	PutsWaiting int   // goroutines blocked in Put now
+	GetsWaiting int   // goroutines blocked in Get now
+	PutWait     Wait  // time spent blocked in Put
+	GetWait     Wait  // time spent blocked in Get
+}
+
+// Wait sums up the times goroutines were blocked.
+type Wait struct {
+	Count int64 // number of calls that blocked
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Mean returns the average blocked time, 0 if no call blocked.
+func (w Wait) Mean() time.Duration {
+	if w.Count == 0 {
+		return 0
+	}
+	return w.Total / time.Duration(w.Count)
+}
+
+func (w *Wait) observe(d time.Duration) {
+	w.Count++
+	w.Total += d
+	w.Max = max(w.Max, d)
+}
+
+// New returns an empty queue holding up to capacity values.
+func New[T any](capacity int) *Queue[T] {
+	if capacity < 1 {
+		panic("queue: capacity must be at least 1")
+	}
+	return &Queue[T]{buf: make([]T, capacity), changed: make(chan struct{})}
+}
+
+// broadcast wakes up the waiting goroutines, q.mux must be held.
+func (q *Queue[T]) broadcast() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// put adds v, q.mux must be held and the queue not full.
+func (q *Queue[T]) put(v T) {
+	q.buf[(q.head+q.n)%len(q.buf)] = v
+	q.n++
+	q.stats.Puts++
+	q.stats.HighWater = max(q.stats.HighWater, q.n)
+	q.broadcast()
+}
+
+// get removes the first value, q.mux must be held and the queue not empty.
+func (q *Queue[T]) get() T {
+	var zero T
+	v := q.buf[q.head]
+	q.buf[q.head] = zero // don't keep a reference to v
+	q.head = (q.head + 1) % len(q.buf)
+	q.n--
+	q.stats.Gets++
+	q.broadcast()
+	return v
+}
+
+// Put adds v at the end of the queue, waiting for room if it is full.
+// It returns ErrClosed if the queue is closed, and ctx.Err() if ctx is done before there is room.
+func (q *Queue[T]) Put(ctx context.Context, v T) error {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	var start time.Time
+	for !q.closed && q.n == len(q.buf) {
+		if start.IsZero() {
+			start = time.Now()
+			q.stats.PutsWaiting++
+			defer func() {
+				q.stats.PutsWaiting--
+				q.stats.PutWait.observe(time.Since(start))
+			}()
+		}
+		if err := q.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if q.closed {
+		return ErrClosed
+	}
+	q.put(v)
+	return nil
+}
+
+// TryPut adds v at the end of the queue if there is room, it returns ErrFull (and counts a drop) if not.
+func (q *Queue[T]) TryPut(v T) error {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.n == len(q.buf) {
+		q.stats.Drops++
+		return ErrFull
+	}
+	q.put(v)
+	return nil
+}
+
+// Get removes and returns the first value of the queue, waiting for one if it is empty.
+// Like a receive from a closed channel, it returns the values left after Close, then ErrClosed.
+// It returns ctx.Err() if ctx is done before a value comes.
+func (q *Queue[T]) Get(ctx context.Context) (T, error) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	var start time.Time
+	for !q.closed && q.n == 0 {
+		if start.IsZero() {
+			start = time.Now()
+			q.stats.GetsWaiting++
+			defer func() {
+				q.stats.GetsWaiting--
+				q.stats.GetWait.observe(time.Since(start))
+			}()
+		}
+		if err := q.wait(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	if q.n == 0 {
+		var zero T
+		return zero, ErrClosed
+	}
+	return q.get(), nil
+}
+
+// TryGet removes and returns the first value of the queue if there is one,
+// it returns ErrEmpty if not, or ErrClosed if the queue is also closed.
+func (q *Queue[T]) TryGet() (T, error) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if q.n == 0 {
+		var zero T
+		if q.closed {
+			return zero, ErrClosed
+		}
+		return zero, ErrEmpty
+	}
+	return q.get(), nil
+}
+
+// wait releases q.mux until the queue changes or ctx is done, q.mux must be held.
+func (q *Queue[T]) wait(ctx context.Context) error {
+	changed := q.changed
+	q.mux.Unlock()
+	defer q.mux.Lock()
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close closes the queue: Put fails from now on, Get returns the values left then fails.
+// The goroutines blocked in Put or Get wake up. Closing a closed queue does nothing.
+func (q *Queue[T]) Close() {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if !q.closed {
+		q.closed = true
+		q.broadcast()
+	}
+}
+
+// All returns an iterator over the values got from the queue until it is closed and empty, or ctx is done,
+// like a for-range loop over a channel.
+func (q *Queue[T]) All(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, err := q.Get(ctx)
+			if err != nil || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of values in the queue.
+func (q *Queue[T]) Len() int {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	return q.n
+}
+
+// Cap returns the capacity of the queue.
+func (q *Queue[T]) Cap() int {
+	return len(q.buf)
+}
+
+// Stats returns the statistics of the queue.
+func (q *Queue[T]) Stats() Stats {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	s := q.stats
+	s.Len = q.n
+	s.Cap = len(q.buf)
+	return s
+}
+
+// Snapshot returns the statistics as keyed values, so that a queue is a metrics.Collector.
+func (q *Queue[T]) Snapshot() map[string]int {
+	s := q.Stats()
+	return map[string]int{
+		"len":             s.Len,
+		"cap":             s.Cap,
+		"high_water":      s.HighWater,
+		"puts":            int(s.Puts),
+		"gets":            int(s.Gets),
+		"drops":           int(s.Drops),
+		"puts_waiting":    s.PutsWaiting,
+		"gets_waiting":    s.GetsWaiting,
+		"put_wait_micros": int(s.PutWait.Total / time.Microsecond),
+		"get_wait_micros": int(s.GetWait.Total / time.Microsecond),
+	}
+}
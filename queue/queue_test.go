@@ -0,0 +1,174 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// blocked waits until puts goroutines are blocked in Put and gets in Get.
+func blocked(q *Queue[int], puts, gets int) {
+	for {
+		s := q.Stats()
+		if s.PutsWaiting == puts && s.GetsWaiting == gets {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCloseWakesUp(t *testing.T) {
+	// Blocked on an empty queue.
+	q := New[int](2)
+	errs := make(chan error, 4)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := q.Get(context.Background())
+			errs <- err
+		}()
+	}
+	blocked(q, 0, 2)
+	q.Close()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != ErrClosed {
+			t.Errorf("Get: got %v, want ErrClosed", err)
+		}
+	}
+
+	// Blocked on a full queue.
+	q = New[int](1)
+	q.Put(context.Background(), 1)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- q.Put(context.Background(), 2) }()
+	}
+	blocked(q, 2, 0)
+	q.Close()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != ErrClosed {
+			t.Errorf("Put: got %v, want ErrClosed", err)
+		}
+	}
+	// The value put before Close is still there, then Get fails.
+	if v, err := q.Get(context.Background()); v != 1 || err != nil {
+		t.Errorf("Get after Close: got %d, %v, want 1", v, err)
+	}
+	if _, err := q.Get(context.Background()); err != ErrClosed {
+		t.Errorf("Get of a closed empty queue: got %v, want ErrClosed", err)
+	}
+	if _, err := q.TryGet(); err != ErrClosed {
+		t.Errorf("TryGet: got %v, want ErrClosed", err)
+	}
+	if err := q.TryPut(1); err != ErrClosed {
+		t.Errorf("TryPut: got %v, want ErrClosed", err)
+	}
+	q.Close() // no-op
+	if s := q.Stats(); s.PutsWaiting != 0 || s.GetsWaiting != 0 || s.PutWait.Count != 2 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestContextDone(t *testing.T) {
+	q := New[int](1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get: got %v, want DeadlineExceeded", err)
+	}
+	q.Put(context.Background(), 1)
+	if err := q.Put(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Put: got %v, want DeadlineExceeded", err)
+	}
+	// The value not put didn't take the place of the first one.
+	if v, _ := q.TryGet(); v != 1 || q.Len() != 0 {
+		t.Errorf("got %d with %d left, want 1 and nothing left", v, q.Len())
+	}
+
+	// A ctx cancelled while waiting.
+	ctx, cancel = context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := q.Get(ctx)
+		errs <- err
+	}()
+	blocked(q, 0, 1)
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("Get: got %v, want Canceled", err)
+	}
+	s := q.Stats()
+	if s.GetsWaiting != 0 || s.GetWait.Count != 2 || s.PutWait.Count != 1 {
+		t.Errorf("got %+v", s)
+	}
+	if s.GetWait.Max < 10*time.Millisecond || s.GetWait.Total < s.GetWait.Max || s.GetWait.Mean() > s.GetWait.Max {
+		t.Errorf("got %+v", s.GetWait)
+	}
+}
+
+func TestWraparound(t *testing.T) {
+	q := New[int](3)
+	var got []int
+	next := 0
+	// Fill up, take one out: the head goes around the ring four times.
+	for i := 0; i < 10; i++ {
+		for q.TryPut(next) == nil {
+			next++
+		}
+		v, err := q.TryGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	q.Close()
+	for v := range q.All(context.Background()) {
+		got = append(got, v)
+	}
+	if next != 12 || !slices.Equal(got, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}) {
+		t.Errorf("put %d, got %v", next, got)
+	}
+	if _, err := q.TryGet(); err != ErrClosed {
+		t.Errorf("TryGet: got %v, want ErrClosed", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	q := New[int](3)
+	q.Put(context.Background(), 1)
+	q.Put(context.Background(), 2)
+	q.Get(context.Background())
+	q.TryPut(3)
+	q.TryPut(4)
+	q.TryPut(5) // full
+	q.TryGet()
+	q.TryGet()
+	if _, err := q.TryGet(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.TryGet(); err != ErrEmpty {
+		t.Errorf("TryGet: got %v, want ErrEmpty", err)
+	}
+	want := Stats{Len: 0, Cap: 3, HighWater: 3, Puts: 4, Gets: 4, Drops: 1}
+	if s := q.Stats(); s != want {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+	snap := q.Snapshot()
+	for k, v := range map[string]int{"len": 0, "cap": 3, "high_water": 3, "puts": 4, "gets": 4, "drops": 1, "get_wait_micros": 0} {
+		if snap[k] != v {
+			t.Errorf("Snapshot[%q]: got %d, want %d", k, snap[k], v)
+		}
+	}
+	if q.Cap() != 3 {
+		t.Errorf("Cap: got %d", q.Cap())
+	}
+}
+
+func TestNewPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New(0) didn't panic")
+		}
+	}()
+	New[int](0)
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang-demo/clock"
+)
+
+// Keyed holds a separate limiter per key, e.g. per client ID or per host,
+// so that one busy client does not use up the rate of the others.
+type Keyed struct {
+	newLimiter func(key string) Limiter
+	clock      clock.Clock
+
+	mux sync.Mutex
+	v   map[string]*keyedEntry
+}
+
+type keyedEntry struct {
+	l        Limiter
+	lastUsed time.Time
+}
+
+// NewKeyed returns a Keyed creating the limiter of a key with newLimiter the first time the key is used.
+// The clock, nil meaning the real clock, is only used to tell when keys were last used, see Evict.
+func NewKeyed(newLimiter func(key string) Limiter, c clock.Clock) *Keyed {
+	return &Keyed{newLimiter: newLimiter, clock: clock.Or(c), v: make(map[string]*keyedEntry)}
+}
+
+// Limiter returns the limiter of the given key, creating it if needed.
+func (k *Keyed) Limiter(key string) Limiter {
+	now := k.clock.Now()
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	e, ok := k.v[key]
+	if !ok {
+		e = &keyedEntry{l: k.newLimiter(key)}
+		k.v[key] = e
+	}
+	e.lastUsed = now
+	return e.l
+}
+
+// Allow reports whether an event for key may happen now, and if so counts it.
+func (k *Keyed) Allow(key string) bool {
+	return k.Limiter(key).Allow()
+}
+
+// Wait blocks until an event for key may happen, or until ctx is done.
+func (k *Keyed) Wait(ctx context.Context, key string) error {
+	return k.Limiter(key).Wait(ctx)
+}
+
+// Reserve counts an event for key and tells when it may happen.
+func (k *Keyed) Reserve(key string) *Reservation {
+	return k.Limiter(key).Reserve()
+}
+
+// Len returns the number of keys with a limiter.
+func (k *Keyed) Len() int {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	return len(k.v)
+}
+
+// Evict forgets the limiters of the keys not used for idle, so that the keys of gone clients don't pile up.
+// idle should be long enough for their buckets to be back to their initial state,
+// otherwise an evicted client gets a fresh burst too early.
+func (k *Keyed) Evict(idle time.Duration) int {
+	now := k.clock.Now()
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	n := 0
+	for key, e := range k.v {
+		if now.Sub(e.lastUsed) >= idle {
+			delete(k.v, key)
+			n++
+		}
+	}
+	return n
+}
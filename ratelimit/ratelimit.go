@@ -0,0 +1,198 @@
+// Package ratelimit limits how often events may happen, e.g. how many requests a client may send per second.
+// Where `ClockBoom` reacts to a ticker at a fixed rate, a limiter lets the callers go as fast as they like
+// up to the rate, and makes them wait or turns them away beyond it.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang-demo/clock"
+)
+
+// Limiter is implemented by TokenBucket, LeakyBucket and the limiters returned by Keyed.
+type Limiter interface {
+	// Allow reports whether an event may happen now, and if so counts it.
+	Allow() bool
+	// Wait blocks until an event may happen, or until ctx is done.
+	Wait(ctx context.Context) error
+	// Reserve counts an event and tells when it may happen.
+	Reserve() *Reservation
+}
+
+var (
+	// ErrFull is returned by Wait when a LeakyBucket has no room left for the event.
+	ErrFull = errors.New("ratelimit: bucket full")
+	// ErrDeadline is returned by Wait when ctx would be done before the event may happen.
+	ErrDeadline = errors.New("ratelimit: wait would exceed context deadline")
+)
+
+// bucket implements both kinds of buckets with the "generic cell rate algorithm": instead of a count of tokens,
+// it keeps tat, the theoretical arrival time of the next event if events were perfectly spaced by interval.
+// An event at t conforms if t >= tat - tolerance, the tolerance being how early events may come, i.e. the burst.
+type bucket struct {
+	clock     clock.Clock
+	interval  time.Duration
+	tolerance time.Duration // (burst-1) * interval
+	maxDelay  time.Duration // how far ahead events may be reserved, < 0 for no limit
+
+	mux sync.Mutex
+	tat time.Time
+}
+
+func newBucket(interval time.Duration, burst int, maxDelay time.Duration, c clock.Clock) bucket {
+	if interval <= 0 {
+		panic("ratelimit: non-positive interval")
+	}
+	if burst < 1 {
+		panic("ratelimit: burst must be at least 1")
+	}
+	return bucket{
+		clock:     clock.Or(c),
+		interval:  interval,
+		tolerance: time.Duration(burst-1) * interval,
+		maxDelay:  maxDelay,
+	}
+}
+
+// reserve counts an event if it may happen within maxDelay (or now if maxDelay is 0).
+func (b *bucket) reserve(maxDelay time.Duration) *Reservation {
+	now := b.clock.Now()
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	tat := b.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	at := tat.Add(-b.tolerance)
+	if at.Before(now) {
+		at = now
+	}
+	if maxDelay >= 0 && at.Sub(now) > maxDelay {
+		return &Reservation{clock: b.clock, at: at}
+	}
+	b.tat = tat.Add(b.interval)
+	return &Reservation{b: b, clock: b.clock, ok: true, at: at}
+}
+
+// Allow reports whether an event may happen now, and if so counts it.
+func (b *bucket) Allow() bool {
+	return b.reserve(0).ok
+}
+
+// Reserve counts an event and returns when it may happen. The caller must wait for Delay
+// before acting, or Cancel the reservation. For a LeakyBucket, the reservation fails if the bucket is full.
+func (b *bucket) Reserve() *Reservation {
+	return b.reserve(b.maxDelay)
+}
+
+// Wait blocks until an event may happen and counts it. It returns an error without waiting
+// if the bucket is full or ctx would be done first, and ctx.Err() if ctx is done while waiting.
+// A ctx already done counts nothing, even when the event could happen now.
+func (b *bucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return wait(ctx, b.Reserve())
+}
+
+func wait(ctx context.Context, r *Reservation) error {
+	if !r.OK() {
+		return ErrFull
+	}
+	d := r.Delay()
+	if d == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(r.at) {
+		r.Cancel()
+		return ErrDeadline
+	}
+	t := r.clock.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// cancel gives back the interval reserved for an event that will not happen.
+func (b *bucket) cancel() {
+	now := b.clock.Now()
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.tat = b.tat.Add(-b.interval)
+	if b.tat.Before(now) {
+		b.tat = now
+	}
+}
+
+// Reservation is an event counted by a limiter, that may happen at a given time.
+type Reservation struct {
+	b     *bucket // nil if not ok
+	clock clock.Clock
+	ok    bool
+	at    time.Time
+
+	once sync.Once
+}
+
+// OK reports whether the event was counted. If not, the limiter did not have room for it.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Time returns when the event may happen.
+func (r *Reservation) Time() time.Time {
+	return r.at
+}
+
+// Delay returns how long to wait before the event may happen, 0 if it may happen now.
+func (r *Reservation) Delay() time.Duration {
+	return max(0, r.at.Sub(r.clock.Now()))
+}
+
+// Cancel tells the limiter that the event will not happen, so later events may happen sooner.
+// It does nothing once the time of the event has passed.
+func (r *Reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+	r.once.Do(func() {
+		if r.at.After(r.clock.Now()) {
+			r.b.cancel()
+		}
+	})
+}
+
+// TokenBucket is a Limiter allowing one event per interval on average, with bursts:
+// the bucket holds up to burst tokens, refilled at one per interval, and each event takes one.
+// Events wait for a token when the bucket is empty.
+type TokenBucket struct {
+	bucket
+}
+
+// NewTokenBucket returns a full token bucket. A nil clock means the real clock.
+func NewTokenBucket(interval time.Duration, burst int, c clock.Clock) *TokenBucket {
+	return &TokenBucket{newBucket(interval, burst, -1, c)}
+}
+
+// LeakyBucket is a Limiter letting events through at exactly one per interval, without bursts:
+// events queue in the bucket, which leaks one event per interval. When `capacity` events are waiting,
+// the bucket is full and new events are turned away.
+type LeakyBucket struct {
+	bucket
+}
+
+// NewLeakyBucket returns an empty leaky bucket holding up to capacity waiting events. A nil clock means the real clock.
+func NewLeakyBucket(interval time.Duration, capacity int, c clock.Clock) *LeakyBucket {
+	if capacity < 0 {
+		panic("ratelimit: negative capacity")
+	}
+	return &LeakyBucket{newBucket(interval, 1, time.Duration(capacity)*interval, c)}
+}
@@ -0,0 +1,213 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-demo/clock"
+)
+
+var epoch = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+const interval = 100 * time.Millisecond
+
+// allowed returns how many events of n in a row l allows.
+func allowed(l Limiter, n int) int {
+	k := 0
+	for i := 0; i < n; i++ {
+		if l.Allow() {
+			k++
+		}
+	}
+	return k
+}
+
+// step advances the clock, then tries 10 events in a row.
+type step struct {
+	advance time.Duration
+	want    int // events allowed
+}
+
+func TestAllow(t *testing.T) {
+	tests := []struct {
+		name  string
+		new   func(clock.Clock) Limiter
+		steps []step
+	}{
+		{"token bucket", func(c clock.Clock) Limiter { return NewTokenBucket(interval, 3, c) }, []step{
+			{0, 3},                // the burst
+			{interval / 2, 0},     // half a token
+			{interval / 2, 1},     // a whole one
+			{interval, 1},         // one per interval
+			{time.Hour, 3},        // never more than the burst
+			{3 * interval / 2, 1}, // the half left over counts later
+			{interval / 2, 1},
+		}},
+		{"leaky bucket", func(c clock.Clock) Limiter { return NewLeakyBucket(interval, 5, c) }, []step{
+			{0, 1}, // no burst, whatever the capacity
+			{interval / 2, 0},
+			{interval / 2, 1},
+			{time.Hour, 1},
+		}},
+	}
+	for _, tt := range tests {
+		f := clock.NewFake(epoch)
+		l := tt.new(f)
+		for i, s := range tt.steps {
+			f.Advance(s.advance)
+			if got := allowed(l, 10); got != s.want {
+				t.Errorf("%s, step %d: allowed %d, want %d", tt.name, i, got, s.want)
+			}
+		}
+	}
+}
+
+func TestReserveCancel(t *testing.T) {
+	f := clock.NewFake(epoch)
+	tb := NewTokenBucket(interval, 2, f)
+	var rs []*Reservation
+	for i := 0; i < 4; i++ {
+		rs = append(rs, tb.Reserve())
+	}
+	for i, want := range []time.Duration{0, 0, interval, 2 * interval} {
+		if !rs[i].OK() || rs[i].Delay() != want || !rs[i].Time().Equal(epoch.Add(want)) {
+			t.Errorf("reservation %d: got %v, %v, want a delay of %v", i, rs[i].OK(), rs[i].Delay(), want)
+		}
+	}
+	// Cancelling the last one gives its interval back, once.
+	rs[3].Cancel()
+	rs[3].Cancel()
+	if d := tb.Reserve().Delay(); d != 2*interval {
+		t.Errorf("after Cancel: got a delay of %v, want %v", d, 2*interval)
+	}
+	// Once its time has passed, a reservation can't be cancelled.
+	f.Advance(interval)
+	rs[2].Cancel()
+	if d := tb.Reserve().Delay(); d != 2*interval {
+		t.Errorf("after a late Cancel: got a delay of %v, want %v", d, 2*interval)
+	}
+	if d := rs[1].Delay(); d != 0 {
+		t.Errorf("passed reservation: got a delay of %v", d)
+	}
+
+	// A leaky bucket turns events away beyond its capacity.
+	lb := NewLeakyBucket(interval, 2, f)
+	for i, want := range []time.Duration{0, interval, 2 * interval} {
+		if r := lb.Reserve(); !r.OK() || r.Delay() != want {
+			t.Errorf("leaky reservation %d: got %v, %v, want a delay of %v", i, r.OK(), r.Delay(), want)
+		}
+	}
+	r := lb.Reserve()
+	if r.OK() {
+		t.Error("full leaky bucket: got a reservation")
+	}
+	r.Cancel() // no-op
+	f.Advance(interval)
+	if r := lb.Reserve(); !r.OK() || r.Delay() != 2*interval {
+		t.Errorf("after a leak: got %v, %v", r.OK(), r.Delay())
+	}
+	if lb := NewLeakyBucket(interval, 0, f); !lb.Reserve().OK() || lb.Reserve().OK() {
+		t.Error("capacity 0: want one event only")
+	}
+}
+
+func TestWait(t *testing.T) {
+	for _, l := range []struct {
+		name string
+		new  func(clock.Clock, time.Duration) Limiter
+	}{
+		{"token bucket", func(c clock.Clock, d time.Duration) Limiter { return NewTokenBucket(d, 1, c) }},
+		{"leaky bucket", func(c clock.Clock, d time.Duration) Limiter { return NewLeakyBucket(d, 1, c) }},
+	} {
+		f := clock.NewFake(epoch)
+		lim := l.new(f, interval)
+
+		// A ctx already done takes nothing, even when an event could happen now.
+		cancelled, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := lim.Wait(cancelled); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: cancelled ctx: got %v", l.name, err)
+		}
+		if err := lim.Wait(context.Background()); err != nil {
+			t.Errorf("%s: first Wait: %v", l.name, err)
+		}
+
+		// The next event waits for an interval.
+		done := make(chan error)
+		go func() { done <- lim.Wait(context.Background()) }()
+		f.BlockUntil(1)
+		f.Advance(interval)
+		if err := <-done; err != nil {
+			t.Errorf("%s: second Wait: %v", l.name, err)
+		}
+
+		// Cancelled while waiting: the interval is given back.
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() { done <- lim.Wait(ctx) }()
+		f.BlockUntil(1)
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: Wait cancelled while waiting: got %v", l.name, err)
+		}
+		if d := lim.Reserve().Delay(); d != interval {
+			t.Errorf("%s: after a cancelled Wait: got a delay of %v, want %v", l.name, d, interval)
+		}
+		if f.Pending() != 0 {
+			t.Errorf("%s: %d timers left", l.name, f.Pending())
+		}
+
+		// A deadline before the event fails right away and gives the interval back too.
+		// The deadline is real time: the fake clock starts at the real time, with events an hour apart.
+		f = clock.NewFake(time.Now())
+		lim = l.new(f, time.Hour)
+		lim.Allow()
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		if err := lim.Wait(ctx); !errors.Is(err, ErrDeadline) {
+			t.Errorf("%s: Wait past the deadline: got %v, want ErrDeadline", l.name, err)
+		}
+		cancel()
+		if d := lim.Reserve().Delay(); d != time.Hour {
+			t.Errorf("%s: after ErrDeadline: got a delay of %v, want 1h", l.name, d)
+		}
+	}
+
+	// A full leaky bucket doesn't wait.
+	lb := NewLeakyBucket(interval, 0, clock.NewFake(epoch))
+	lb.Allow()
+	if err := lb.Wait(context.Background()); !errors.Is(err, ErrFull) {
+		t.Errorf("full leaky bucket: got %v, want ErrFull", err)
+	}
+}
+
+func TestKeyedEvict(t *testing.T) {
+	f := clock.NewFake(epoch)
+	k := NewKeyed(func(string) Limiter { return NewTokenBucket(time.Minute, 1, f) }, f)
+	k.Allow("a")
+	f.Advance(time.Second)
+	k.Allow("b")
+	if k.Allow("a") || k.Allow("b") {
+		t.Error("second event of a key allowed")
+	}
+	// "a" was used again, at the same time as "b".
+	f.Advance(time.Second)
+	if n := k.Evict(2 * time.Second); n != 0 {
+		t.Errorf("Evict(2s): got %d, want 0", n)
+	}
+	k.Limiter("b")
+	f.Advance(time.Second)
+	if n := k.Evict(2 * time.Second); n != 1 || k.Len() != 1 {
+		t.Errorf("Evict(2s) 1s later: got %d with %d keys left, want 1 and 1", n, k.Len())
+	}
+	// An evicted key starts over with a fresh burst, the others keep their bucket.
+	if !k.Allow("a") {
+		t.Error("evicted key: event not allowed")
+	}
+	if k.Allow("b") {
+		t.Error("kept key: second event allowed")
+	}
+	if n := k.Evict(0); n != 2 || k.Len() != 0 {
+		t.Errorf("Evict(0): got %d with %d keys left", n, k.Len())
+	}
+}
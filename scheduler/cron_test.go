@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"golang-demo/clock"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		expr, from string
+		want       []string // the following runs, none if empty
+	}{
+		// Steps.
+		{"*/15 * * * *", "2026-10-19 10:07", []string{"2026-10-19 10:15", "2026-10-19 10:30", "2026-10-19 10:45", "2026-10-19 11:00"}},
+		{"5/20 * * * *", "2026-10-19 10:07", []string{"2026-10-19 10:25", "2026-10-19 10:45", "2026-10-19 11:05"}},
+		// Strictly after: a matching time is not its own next run.
+		{"0 * * * *", "2026-10-19 10:00", []string{"2026-10-19 11:00"}},
+		// Ranges, with a step, and lists.
+		{"0 9-17/4 * * *", "2026-10-19 08:00", []string{"2026-10-19 09:00", "2026-10-19 13:00", "2026-10-19 17:00", "2026-10-20 09:00"}},
+		{"30 8 * * 1-5", "2026-10-23 09:00", []string{"2026-10-26 08:30", "2026-10-27 08:30"}},
+		{"0 0 1,15 * *", "2026-10-19 10:07", []string{"2026-11-01 00:00", "2026-11-15 00:00", "2026-12-01 00:00"}},
+		// Sunday is 0 or 7.
+		{"0 0 * * 0", "2026-10-19 10:07", []string{"2026-10-25 00:00", "2026-11-01 00:00"}},
+		{"0 0 * * 7", "2026-10-19 10:07", []string{"2026-10-25 00:00", "2026-11-01 00:00"}},
+		{"0 0 * * 5-7", "2026-10-19 10:07", []string{"2026-10-23 00:00", "2026-10-24 00:00", "2026-10-25 00:00", "2026-10-30 00:00"}},
+		// Both day fields restricted: either matches (the 1st, or a Monday).
+		{"0 0 1 * 1", "2026-10-27 12:00", []string{"2026-11-01 00:00", "2026-11-02 00:00", "2026-11-09 00:00"}},
+		// Only one restricted: it alone decides.
+		{"0 0 * * 1", "2026-10-27 12:00", []string{"2026-11-02 00:00"}},
+		{"0 0 1 * *", "2026-10-27 12:00", []string{"2026-11-01 00:00", "2026-12-01 00:00"}},
+		// A field starting with `*` counts as unrestricted even with a step: a day must match both, Mondays on odd days here.
+		{"0 0 */2 * 1", "2026-10-27 12:00", []string{"2026-11-09 00:00", "2026-11-23 00:00", "2026-12-07 00:00"}},
+		{"0 0 1 * */2", "2026-10-27 12:00", []string{"2026-11-01 00:00", "2026-12-01 00:00"}},
+		// Months without the day are skipped, the 29th of February waits for a leap year.
+		{"0 0 31 * *", "2026-11-01 00:00", []string{"2026-12-31 00:00", "2027-01-31 00:00", "2027-03-31 00:00"}},
+		{"0 0 29 2 *", "2026-10-19 10:07", []string{"2028-02-29 00:00"}},
+		// Descriptors.
+		{"@hourly", "2026-10-19 10:07", []string{"2026-10-19 11:00"}},
+		{"@weekly", "2026-10-19 10:07", []string{"2026-10-25 00:00"}},
+		{"@yearly", "2026-10-19 10:07", []string{"2027-01-01 00:00"}},
+		// Impossible dates never match.
+		{"0 0 30 2 *", "2026-10-19 10:07", nil},
+		{"0 0 31 4,6,9,11 *", "2026-10-19 10:07", nil},
+	}
+	for _, tt := range tests {
+		sched, err := Cron(tt.expr)
+		if err != nil {
+			t.Errorf("Cron(%q): %v", tt.expr, err)
+			continue
+		}
+		at := date(tt.from)
+		for _, w := range tt.want {
+			next, ok := sched.Next(at)
+			if !ok || !next.Equal(date(w)) {
+				t.Errorf("%q after %v: got %v, %v, want %s", tt.expr, at, next, ok, w)
+				break
+			}
+			at = next
+		}
+		if len(tt.want) == 0 {
+			if next, ok := sched.Next(at); ok {
+				t.Errorf("%q after %v: got %v, want no run", tt.expr, at, next)
+			}
+		}
+	}
+}
+
+func TestCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-2-3 * * * *",
+		"@never",
+	} {
+		if _, err := Cron(expr); err == nil {
+			t.Errorf("Cron(%q): got no error", expr)
+		}
+	}
+}
+
+func TestCronScheduler(t *testing.T) {
+	f := clock.NewFake(date("2026-10-19 10:07"))
+	s := idle(f)
+	e, err := s.Cron("*/15 * * * *", nop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next, _ := e.Next(); !next.Equal(date("2026-10-19 10:15")) {
+		t.Fatalf("Next: got %v, want 10:15", next)
+	}
+	if n := fireAt(s, f, date("2026-10-19 10:14")); n != 0 {
+		t.Errorf("at 10:14: got %d runs, want 0", n)
+	}
+	if n := fireAt(s, f, date("2026-10-19 10:15")); n != 1 {
+		t.Errorf("at 10:15: got %d runs, want 1", n)
+	}
+	if next, _ := e.Next(); !next.Equal(date("2026-10-19 10:30")) {
+		t.Errorf("Next: got %v, want 10:30", next)
+	}
+	if _, err := s.Cron("0 0 30 2", nop); err == nil {
+		t.Error("bad expression: got no error")
+	}
+}
@@ -0,0 +1,336 @@
+// Package scheduler runs jobs at fixed intervals, after a delay, or on cron schedules,
+// like `ClockBoom` reacting to `time.Tick` and `time.After`, but for any number of jobs run by a pool of workers.
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golang-demo/clock"
+)
+
+// Schedule tells when a job runs.
+type Schedule interface {
+	// Next returns the first run time strictly after t, or false if there are no more runs.
+	Next(t time.Time) (time.Time, bool)
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) (time.Time, bool) {
+	return t.Add(time.Duration(e)), true
+}
+
+// Every returns a schedule running every d, starting d after the job is added.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("scheduler: non-positive interval")
+	}
+	return every(d)
+}
+
+type once struct {
+	at time.Time
+}
+
+func (o once) Next(t time.Time) (time.Time, bool) {
+	return o.at, t.Before(o.at)
+}
+
+// At returns a schedule running once at the given time. A time already passed when the job is added,
+// like that of After(0), runs the job right away.
+func At(t time.Time) Schedule {
+	return once{t}
+}
+
+// MissedPolicy tells what to do with the runs that could not start on time,
+// e.g. because the process was suspended or the workers were all busy.
+type MissedPolicy int
+
+const (
+	// RunOnce coalesces all the missed runs of a job into a single run.
+	RunOnce MissedPolicy = iota
+	// RunAll runs every missed run.
+	RunAll
+	// Skip drops the missed runs, the job runs again at its next time to come.
+	Skip
+)
+
+// Options tune how a job is run.
+type Options struct {
+	// Jitter delays every run by a random duration in [0, Jitter), to spread jobs scheduled at the same times.
+	Jitter time.Duration
+	// Missed is what to do with the runs that are late by more than Grace.
+	Missed MissedPolicy
+	// Grace is how late a run may start before it counts as missed, one second if zero.
+	Grace time.Duration
+}
+
+// Job is the function run by the scheduler. ctx is cancelled when the job is cancelled or the scheduler stopped.
+type Job func(ctx context.Context)
+
+// Entry is a job added to a Scheduler.
+type Entry struct {
+	s      *Scheduler
+	sched  Schedule
+	fn     Job
+	opts   Options
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// guarded by s.mux
+	next   time.Time // nominal time of the next run
+	fireAt time.Time // next plus jitter
+	index  int       // in s.queue, -1 when not scheduled
+	runs   int
+}
+
+// Next returns when the job runs next, false if it will not run any more.
+func (e *Entry) Next() (time.Time, bool) {
+	e.s.mux.Lock()
+	defer e.s.mux.Unlock()
+	return e.fireAt, e.index >= 0
+}
+
+// Runs returns how many runs of the job have been handed to the workers so far.
+func (e *Entry) Runs() int {
+	e.s.mux.Lock()
+	defer e.s.mux.Unlock()
+	return e.runs
+}
+
+// Cancel removes the job from the scheduler and cancels the context of its running instances.
+func (e *Entry) Cancel() {
+	e.cancel()
+	e.s.mux.Lock()
+	if e.index >= 0 {
+		heap.Remove(&e.s.queue, e.index)
+	}
+	e.s.mux.Unlock()
+	e.s.poke()
+}
+
+// Scheduler runs jobs on a pool of workers. Time is read from a clock.Clock, which can be a fake one in tests.
+type Scheduler struct {
+	clock  clock.Clock
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	mux   sync.Mutex
+	cond  *sync.Cond // signals the workers that ready has runs or the scheduler stopped
+	queue entryQueue // jobs waiting for their next run, earliest first
+	ready []*Entry   // runs waiting for a worker
+}
+
+// New starts a scheduler running jobs on the given number of workers. A nil clock means the real clock.
+func New(workers int, c clock.Clock) *Scheduler {
+	if workers < 1 {
+		panic("scheduler: needs at least one worker")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		clock:  clock.Or(c),
+		ctx:    ctx,
+		cancel: cancel,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mux)
+	s.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go s.work()
+	}
+	go s.loop()
+	return s
+}
+
+// Add schedules fn to run according to sched.
+func (s *Scheduler) Add(sched Schedule, fn Job, opts Options) *Entry {
+	if opts.Grace <= 0 {
+		opts.Grace = time.Second
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	e := &Entry{s: s, sched: sched, fn: fn, opts: opts, ctx: ctx, cancel: cancel, index: -1}
+	s.mux.Lock()
+	if next, ok := first(sched, s.clock.Now()); ok && ctx.Err() == nil {
+		e.setNext(next)
+		heap.Push(&s.queue, e)
+	}
+	s.mux.Unlock()
+	s.poke()
+	return e
+}
+
+// first returns the first run of sched for a job added at now. Next only looks strictly after now,
+// so a one-shot schedule whose time has come would never run: it runs now instead.
+func first(sched Schedule, now time.Time) (time.Time, bool) {
+	if o, ok := sched.(once); ok && !o.at.After(now) {
+		return now, true
+	}
+	return sched.Next(now)
+}
+
+// Every runs fn every d.
+func (s *Scheduler) Every(d time.Duration, fn Job) *Entry {
+	return s.Add(Every(d), fn, Options{})
+}
+
+// After runs fn once, after d. It runs fn right away if d <= 0.
+func (s *Scheduler) After(d time.Duration, fn Job) *Entry {
+	return s.Add(At(s.clock.Now().Add(d)), fn, Options{})
+}
+
+// Cron runs fn according to a cron expression, see Cron.
+func (s *Scheduler) Cron(expr string, fn Job) (*Entry, error) {
+	sched, err := Cron(expr)
+	if err != nil {
+		return nil, err
+	}
+	return s.Add(sched, fn, Options{}), nil
+}
+
+// Stop stops scheduling runs, cancels the context of the running jobs and waits for them to return.
+// Runs waiting for a worker are dropped.
+func (s *Scheduler) Stop() {
+	s.cancel()
+	<-s.done
+	s.mux.Lock()
+	s.ready = nil
+	s.cond.Broadcast()
+	s.mux.Unlock()
+	s.wg.Wait()
+}
+
+func (e *Entry) setNext(next time.Time) {
+	e.next = next
+	e.fireAt = next
+	if e.opts.Jitter > 0 {
+		e.fireAt = next.Add(time.Duration(rand.Int63n(int64(e.opts.Jitter))))
+	}
+}
+
+// poke wakes the loop up to look at the queue again.
+func (s *Scheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+	timer := s.clock.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		timer.Stop()
+		s.mux.Lock()
+		if len(s.queue) > 0 {
+			timer.Reset(s.queue[0].fireAt.Sub(s.clock.Now()))
+		}
+		s.mux.Unlock()
+		select {
+		case <-timer.C():
+			s.fire()
+		case <-s.wake:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// maxCatchUp bounds how many missed runs of a job are counted at once.
+const maxCatchUp = 1000
+
+// fire hands the due runs over to the workers and reschedules their jobs.
+func (s *Scheduler) fire() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	now := s.clock.Now()
+	for len(s.queue) > 0 && !s.queue[0].fireAt.After(now) {
+		e := heap.Pop(&s.queue).(*Entry)
+		if e.ctx.Err() != nil {
+			continue
+		}
+		// Count the runs due by now: the one we woke up for and those that fell due since.
+		onTime, missed := 0, 0
+		start, next, more := e.fireAt, e.next, true
+		for more && !next.After(now) && onTime+missed < maxCatchUp {
+			if now.Sub(start) <= e.opts.Grace {
+				onTime++
+			} else {
+				missed++
+			}
+			next, more = e.sched.Next(next)
+			start = next
+		}
+		runs := onTime
+		switch e.opts.Missed {
+		case RunAll:
+			runs += missed
+		case RunOnce:
+			if missed > 0 && runs == 0 {
+				runs = 1
+			}
+		}
+		for i := 0; i < runs; i++ {
+			s.ready = append(s.ready, e)
+		}
+		e.runs += runs
+		if more {
+			e.setNext(next)
+			heap.Push(&s.queue, e)
+		}
+	}
+	s.cond.Broadcast()
+}
+
+func (s *Scheduler) work() {
+	defer s.wg.Done()
+	for {
+		s.mux.Lock()
+		for len(s.ready) == 0 && s.ctx.Err() == nil {
+			s.cond.Wait()
+		}
+		if s.ctx.Err() != nil {
+			s.mux.Unlock()
+			return
+		}
+		e := s.ready[0]
+		s.ready = s.ready[1:]
+		s.mux.Unlock()
+		if e.ctx.Err() == nil {
+			e.fn(e.ctx)
+		}
+	}
+}
+
+// entryQueue implements heap.Interface, ordered by fireAt.
+type entryQueue []*Entry
+
+func (q entryQueue) Len() int           { return len(q) }
+func (q entryQueue) Less(i, j int) bool { return q[i].fireAt.Before(q[j].fireAt) }
+func (q entryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *entryQueue) Push(x interface{}) {
+	e := x.(*Entry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *entryQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	e.index = -1
+	*q = old[:len(old)-1]
+	return e
+}
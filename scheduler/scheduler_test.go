@@ -0,0 +1,177 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-demo/clock"
+)
+
+var start = time.Date(2026, 10, 19, 10, 7, 0, 0, time.UTC) // a Monday
+
+// idle returns a scheduler without its loop and workers: tests call fire themselves
+// and look at the runs handed over in s.ready, with no goroutine racing them.
+func idle(c clock.Clock) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{clock: c, ctx: ctx, cancel: cancel, wake: make(chan struct{}, 1), done: make(chan struct{})}
+	s.cond = sync.NewCond(&s.mux)
+	return s
+}
+
+// fireAt sets the fake clock to t and hands the due runs over, it returns how many were.
+func fireAt(s *Scheduler, f *clock.Fake, t time.Time) int {
+	f.Set(t)
+	s.mux.Lock()
+	before := len(s.ready)
+	s.mux.Unlock()
+	s.fire()
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.ready) - before
+}
+
+func nop(context.Context) {}
+
+func TestMissedPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy MissedPolicy
+		late   time.Duration // how late after the first run time the scheduler fires
+		want   int
+	}{
+		// On time: the policies don't matter.
+		{"on time/RunOnce", RunOnce, 0, 1},
+		{"on time/RunAll", RunAll, 0, 1},
+		{"on time/Skip", Skip, 0, 1},
+		{"within grace", Skip, time.Second, 1},
+		// Runs at 1m, 2m, 3m, 4m and 5m all missed.
+		{"all missed/RunOnce", RunOnce, 4*time.Minute + 30*time.Second, 1},
+		{"all missed/RunAll", RunAll, 4*time.Minute + 30*time.Second, 5},
+		{"all missed/Skip", Skip, 4*time.Minute + 30*time.Second, 0},
+		// Runs at 1m, 2m, 3m and 4m missed, the one at 5m on time.
+		{"last on time/RunOnce", RunOnce, 4*time.Minute + 500*time.Millisecond, 1},
+		{"last on time/RunAll", RunAll, 4*time.Minute + 500*time.Millisecond, 5},
+		{"last on time/Skip", Skip, 4*time.Minute + 500*time.Millisecond, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := clock.NewFake(start)
+			s := idle(f)
+			e := s.Add(Every(time.Minute), nop, Options{Missed: tt.policy})
+			first, ok := e.Next()
+			if !ok || !first.Equal(start.Add(time.Minute)) {
+				t.Fatalf("Next: got %v, %v, want %v", first, ok, start.Add(time.Minute))
+			}
+			if n := fireAt(s, f, first.Add(tt.late)); n != tt.want {
+				t.Errorf("got %d runs, want %d", n, tt.want)
+			}
+			if e.Runs() != tt.want {
+				t.Errorf("Runs: got %d, want %d", e.Runs(), tt.want)
+			}
+			// Whatever the policy, the job is back on schedule: the next run is the first one to come.
+			want := first.Add(tt.late).Truncate(time.Minute).Add(time.Minute)
+			if next, ok := e.Next(); !ok || !next.Equal(want) {
+				t.Errorf("Next after firing: got %v, %v, want %v", next, ok, want)
+			}
+		})
+	}
+}
+
+func TestOnce(t *testing.T) {
+	f := clock.NewFake(start)
+	s := idle(f)
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Time // first run
+	}{
+		{"future", start.Add(time.Hour), start.Add(time.Hour)},
+		{"now", start, start},
+		{"past", start.Add(-time.Hour), start},
+	}
+	for _, tt := range tests {
+		e := s.Add(At(tt.at), nop, Options{})
+		if next, ok := e.Next(); !ok || !next.Equal(tt.want) {
+			t.Errorf("%s: Next got %v, %v, want %v, true", tt.name, next, ok, tt.want)
+		}
+	}
+	if n := fireAt(s, f, start); n != 2 {
+		t.Errorf("got %d runs now, want 2", n)
+	}
+	if n := fireAt(s, f, start.Add(time.Hour)); n != 1 {
+		t.Errorf("got %d runs in an hour, want 1", n)
+	}
+	if n := fireAt(s, f, start.Add(48*time.Hour)); n != 0 {
+		t.Errorf("got %d more runs, want 0", n)
+	}
+	if len(s.queue) != 0 {
+		t.Errorf("%d jobs left in the queue", len(s.queue))
+	}
+}
+
+func TestAfterRunsNow(t *testing.T) {
+	f := clock.NewFake(start)
+	s := New(2, f)
+	defer s.Stop()
+	ran := make(chan string, 3)
+	s.After(0, func(context.Context) { ran <- "After(0)" })
+	s.After(-time.Minute, func(context.Context) { ran <- "After(-1m)" })
+	s.Add(At(start.Add(-time.Hour)), func(context.Context) { ran <- "At(past)" }, Options{})
+	// The fake clock never moves: the jobs must run anyway.
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of 3 one-shot jobs ran", i)
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	f := clock.NewFake(start)
+	s := New(1, f)
+	defer s.Stop()
+	ran := make(chan time.Time, 10)
+	e := s.Every(time.Minute, func(context.Context) { ran <- f.Now() })
+	for i := 1; i <= 3; i++ {
+		f.Advance(time.Minute)
+		select {
+		case at := <-ran:
+			if want := start.Add(time.Duration(i) * time.Minute); !at.Equal(want) {
+				t.Errorf("run %d at %v, want %v", i, at, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("run %d didn't happen", i)
+		}
+	}
+	if e.Runs() != 3 {
+		t.Errorf("Runs: got %d, want 3", e.Runs())
+	}
+}
+
+func TestCancel(t *testing.T) {
+	f := clock.NewFake(start)
+	s := idle(f)
+	e := s.Add(Every(time.Minute), nop, Options{})
+	e.Cancel()
+	if _, ok := e.Next(); ok {
+		t.Error("cancelled job still scheduled")
+	}
+	if n := fireAt(s, f, start.Add(time.Hour)); n != 0 {
+		t.Errorf("cancelled job ran %d times", n)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	f := clock.NewFake(start)
+	s := idle(f)
+	for i := 0; i < 50; i++ {
+		e := s.Add(Every(time.Minute), nop, Options{Jitter: 10 * time.Second})
+		next, _ := e.Next()
+		if d := next.Sub(start.Add(time.Minute)); d < 0 || d >= 10*time.Second {
+			t.Fatalf("jitter of %v, want [0, 10s)", d)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package syncx
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Group runs tasks in goroutines and waits for them, like a sync.WaitGroup that also collects errors:
+// the first task to fail cancels the context shared by the others, so they can give up early,
+// and a task that panics fails the group instead of crashing the process.
+// The zero value is a group without a context to cancel, ready to use.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc // nil for the zero value
+	wg     sync.WaitGroup
+	sem    chan struct{} // nil without limit
+
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup returns a group and the context of its tasks, derived from ctx.
+// The context is cancelled when a task fails or Wait returns, whichever comes first.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{ctx: ctx, cancel: cancel}, ctx
+}
+
+// SetLimit limits the number of tasks running at the same time to n, n <= 0 meaning no limit.
+// It must be called before Go. Beware that a task calling Go may then wait for a slot held by its parent.
+func (g *Group) SetLimit(n int) {
+	if n <= 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go runs fn in a new goroutine, after waiting for a slot if the group has a limit.
+// The first error returned (or panic) is the one Wait returns.
+func (g *Group) Go(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(fn)
+}
+
+// TryGo runs fn in a new goroutine if the group's limit allows it right now, and reports whether it did.
+func (g *Group) TryGo(fn func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(fn)
+	return true
+}
+
+func (g *Group) start(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+		if err := run(fn); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// run calls fn, turning a panic into a *PanicError.
+func run(fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		if g.cancel != nil {
+			g.cancel(err)
+		}
+	})
+}
+
+// Wait waits for all the tasks to return, then returns the first error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(context.Canceled)
+	}
+	return g.err
+}
+
+// PanicError is the error of a task that panicked.
+type PanicError struct {
+	Value any    // the value passed to panic
+	Stack []byte // the stack of the goroutine when it panicked
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("syncx: task panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error, e.g. a runtime.Error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
@@ -0,0 +1,116 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupFirstError(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	first, second := errors.New("first"), errors.New("second")
+	g.Go(func() error { return first })
+	// The others see the context cancelled by the first error.
+	for i := 0; i < 3; i++ {
+		g.Go(func() error {
+			<-ctx.Done()
+			return second
+		})
+	}
+	if err := g.Wait(); err != first {
+		t.Errorf("Wait: got %v, want %v", err, first)
+	}
+	if cause := context.Cause(ctx); cause != first {
+		t.Errorf("Cause: got %v, want %v", cause, first)
+	}
+
+	// Without error, the context is cancelled by Wait.
+	g, ctx = NewGroup(context.Background())
+	g.Go(func() error { return nil })
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("context not cancelled after Wait")
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	g.Go(func() error { panic("boom") })
+	err := g.Wait()
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("Wait: got %v, want a PanicError of boom", err)
+	}
+	if !strings.Contains(string(pe.Stack), "TestGroupPanic") {
+		t.Errorf("stack without the panicking task:\n%s", pe.Stack)
+	}
+	if context.Cause(ctx) != err {
+		t.Errorf("Cause: got %v", context.Cause(ctx))
+	}
+
+	// A runtime error can be told apart through Unwrap.
+	g, _ = NewGroup(context.Background())
+	g.Go(func() error {
+		var m map[string]int
+		m["a"] = 1
+		return nil
+	})
+	var re runtime.Error
+	if err := g.Wait(); !errors.As(err, &re) {
+		t.Errorf("Wait: got %v, want a runtime.Error", err)
+	}
+}
+
+func TestGroupLimit(t *testing.T) {
+	g, _ := NewGroup(context.Background())
+	g.SetLimit(3)
+	var running, peak atomic.Int32
+	for i := 0; i < 20; i++ {
+		g.Go(func() error {
+			n := running.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+	g.Wait()
+	if p := peak.Load(); p != 3 {
+		t.Errorf("peak: got %d tasks at once, want 3", p)
+	}
+
+	// TryGo doesn't wait for a slot.
+	g, _ = NewGroup(context.Background())
+	g.SetLimit(1)
+	release := make(chan struct{})
+	g.Go(func() error {
+		<-release
+		return nil
+	})
+	if g.TryGo(func() error { return nil }) {
+		t.Error("TryGo with no slot left: got true")
+	}
+	close(release)
+	g.Wait()
+	if !g.TryGo(func() error { return nil }) {
+		t.Error("TryGo with a slot: got false")
+	}
+	g.Wait()
+}
+
+func TestGroupZero(t *testing.T) {
+	var g Group
+	fail := errors.New("fail")
+	g.Go(func() error { return fail })
+	g.Go(func() error { return nil })
+	if err := g.Wait(); err != fail {
+		t.Errorf("Wait: got %v, want %v", err, fail)
+	}
+}
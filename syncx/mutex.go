@@ -0,0 +1,113 @@
+// Package syncx provides instrumented versions of the sync primitives, and higher-level concurrency helpers.
+package syncx
+
+import (
+	"sync"
+	"time"
+)
+
+// Mutex is a sync.Mutex that records how long callers wait for it, how long they hold it,
+// and where the longest holds came from if RecordStacks is on. Its zero value is an unlocked mutex, like sync.Mutex.
+type Mutex struct {
+	mu       sync.Mutex
+	rec      recorder
+	acquired time.Time // when the current holder got the lock, guarded by mu
+	pcs      []uintptr // stack of the current holder if recorded, guarded by mu
+}
+
+// Lock locks m, blocking until it is available.
+func (m *Mutex) Lock() {
+	start := time.Now()
+	contended := !m.mu.TryLock()
+	if contended {
+		m.mu.Lock()
+	}
+	now := time.Now()
+	m.acquired = now
+	m.pcs = callers()
+	m.rec.acquired(now.Sub(start), contended)
+}
+
+// Unlock unlocks m.
+func (m *Mutex) Unlock() {
+	hold, pcs := time.Since(m.acquired), m.pcs
+	m.mu.Unlock()
+	m.rec.released(hold, pcs)
+}
+
+// Stats returns the statistics recorded so far.
+func (m *Mutex) Stats() Stats {
+	return m.rec.snapshot()
+}
+
+// ResetStats clears the statistics.
+func (m *Mutex) ResetStats() {
+	m.rec.reset()
+}
+
+// RWMutex is a sync.RWMutex that records the same statistics as Mutex.
+// Waits are recorded for readers and writers, hold times for writers only:
+// readers share the lock, so there is no single holder to time.
+type RWMutex struct {
+	mu       sync.RWMutex
+	rec      recorder
+	acquired time.Time
+	pcs      []uintptr
+}
+
+// Lock locks rw for writing.
+func (rw *RWMutex) Lock() {
+	start := time.Now()
+	contended := !rw.mu.TryLock()
+	if contended {
+		rw.mu.Lock()
+	}
+	now := time.Now()
+	rw.acquired = now
+	rw.pcs = callers()
+	rw.rec.acquired(now.Sub(start), contended)
+}
+
+// Unlock unlocks rw for writing.
+func (rw *RWMutex) Unlock() {
+	hold, pcs := time.Since(rw.acquired), rw.pcs
+	rw.mu.Unlock()
+	rw.rec.released(hold, pcs)
+}
+
+// RLock locks rw for reading.
+func (rw *RWMutex) RLock() {
+	start := time.Now()
+	contended := !rw.mu.TryRLock()
+	if contended {
+		rw.mu.RLock()
+	}
+	rw.rec.acquired(time.Since(start), contended)
+}
+
+// RUnlock undoes a single RLock call.
+func (rw *RWMutex) RUnlock() {
+	rw.mu.RUnlock()
+}
+
+// RLocker returns a sync.Locker that locks rw for reading.
+func (rw *RWMutex) RLocker() sync.Locker {
+	return rlocker{rw}
+}
+
+type rlocker struct {
+	rw *RWMutex
+}
+
+func (r rlocker) Lock()   { r.rw.RLock() }
+func (r rlocker) Unlock() { r.rw.RUnlock() }
+
+// Stats returns the statistics recorded so far.
+func (rw *RWMutex) Stats() Stats {
+	return rw.rec.snapshot()
+}
+
+// ResetStats clears the statistics.
+func (rw *RWMutex) ResetStats() {
+	rw.rec.reset()
+}
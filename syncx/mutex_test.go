@@ -0,0 +1,140 @@
+package syncx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMutexHold(t *testing.T) {
+	var m Mutex
+	holds := []time.Duration{2 * time.Millisecond, 0, 5 * time.Millisecond}
+	for _, d := range holds {
+		m.Lock()
+		time.Sleep(d)
+		m.Unlock()
+	}
+	s := m.Stats()
+	if s.Acquisitions != 3 || s.Contentions != 0 {
+		t.Errorf("got %d acquisitions, %d contentions, want 3 and 0", s.Acquisitions, s.Contentions)
+	}
+	if s.Hold.Count != 3 || s.Wait.Count != 3 {
+		t.Errorf("got %d holds and %d waits, want 3", s.Hold.Count, s.Wait.Count)
+	}
+	if s.Hold.Max < 5*time.Millisecond || s.Hold.Sum < 7*time.Millisecond {
+		t.Errorf("hold: max %v, sum %v, want at least 5ms and 7ms", s.Hold.Max, s.Hold.Sum)
+	}
+	var n int64
+	for _, c := range s.Hold.Counts {
+		n += c
+	}
+	if n != 3 {
+		t.Errorf("hold histogram: %d in the buckets, want 3", n)
+	}
+	// Longest first, without stacks by default.
+	if len(s.Longest) != 3 || s.Longest[0].Duration != s.Hold.Max || s.Longest[1].Duration < 2*time.Millisecond {
+		t.Errorf("Longest: got %v", s.Longest)
+	}
+	for _, h := range s.Longest {
+		if h.Stack != nil {
+			t.Errorf("stack recorded: %v", h.Stack)
+		}
+	}
+
+	m.ResetStats()
+	if s := m.Stats(); s.Acquisitions != 0 || s.Hold.Count != 0 || len(s.Longest) != 0 {
+		t.Errorf("after ResetStats: got %+v", s)
+	}
+}
+
+func TestMutexWait(t *testing.T) {
+	var m Mutex
+	m.Lock()
+	locked := make(chan struct{})
+	go func() {
+		m.Lock()
+		m.Unlock()
+		close(locked)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	m.Unlock()
+	<-locked
+	s := m.Stats()
+	if s.Acquisitions != 2 || s.Contentions != 1 {
+		t.Errorf("got %d acquisitions, %d contentions, want 2 and 1", s.Acquisitions, s.Contentions)
+	}
+	if s.Wait.Max < 5*time.Millisecond {
+		t.Errorf("wait: max %v, want at least 5ms", s.Wait.Max)
+	}
+}
+
+func TestMutexLongest(t *testing.T) {
+	var m Mutex
+	for i := 0; i < 2*maxLongest; i++ {
+		m.Lock()
+		time.Sleep(time.Duration(i%5) * time.Millisecond)
+		m.Unlock()
+	}
+	s := m.Stats()
+	if len(s.Longest) != maxLongest {
+		t.Fatalf("got %d holds, want %d", len(s.Longest), maxLongest)
+	}
+	for i := 1; i < len(s.Longest); i++ {
+		if s.Longest[i].Duration > s.Longest[i-1].Duration {
+			t.Errorf("Longest not sorted: %v", s.Longest)
+		}
+	}
+	if s.Longest[maxLongest-1].Duration < 2*time.Millisecond {
+		t.Errorf("a short hold was kept: %v", s.Longest)
+	}
+}
+
+func TestMutexStacks(t *testing.T) {
+	RecordStacks(true)
+	defer RecordStacks(false)
+	var m Mutex
+	m.Lock()
+	m.Unlock()
+	s := m.Stats()
+	if len(s.Longest) != 1 || len(s.Longest[0].Stack) == 0 {
+		t.Fatalf("got %v", s.Longest)
+	}
+	// The innermost frame is the caller of Lock.
+	if f := s.Longest[0].Stack[0]; !strings.Contains(f, "TestMutexStacks") {
+		t.Errorf("innermost frame: got %s", f)
+	}
+}
+
+func TestMutexAllocs(t *testing.T) {
+	var m Mutex
+	for i := 0; i < 10; i++ { // fill the longest holds
+		m.Lock()
+		m.Unlock()
+	}
+	if n := testing.AllocsPerRun(100, func() {
+		m.Lock()
+		m.Unlock()
+	}); n != 0 {
+		t.Errorf("Lock and Unlock: %v allocations, want 0", n)
+	}
+}
+
+func TestRWMutex(t *testing.T) {
+	var rw RWMutex
+	rw.RLock()
+	r := rw.RLocker()
+	r.Lock() // readers share the lock
+	r.Unlock()
+	rw.RUnlock()
+	rw.Lock()
+	time.Sleep(time.Millisecond)
+	rw.Unlock()
+	s := rw.Stats()
+	// Waits for readers and writers, holds for writers only.
+	if s.Acquisitions != 3 || s.Contentions != 0 || s.Wait.Count != 3 || s.Hold.Count != 1 {
+		t.Errorf("got %+v", s)
+	}
+	if s.Hold.Max < time.Millisecond {
+		t.Errorf("hold: max %v, want at least 1ms", s.Hold.Max)
+	}
+}
@@ -0,0 +1,208 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang-demo/clock"
+)
+
+// ErrPoolClosed is returned by Get once the pool is closed.
+var ErrPoolClosed = errors.New("syncx: pool closed")
+
+// PoolOptions describe how a Pool manages its resources. Only Create is required.
+type PoolOptions[T any] struct {
+	// Create makes a new resource, e.g. opens a connection.
+	Create func(ctx context.Context) (T, error)
+	// Validate reports whether an idle resource can still be used, it is called before handing it out again.
+	Validate func(T) bool
+	// Destroy releases a resource the pool gets rid of, e.g. closes a connection.
+	Destroy func(T)
+	// MaxOpen bounds the number of resources in use or idle, 0 meaning no limit. Get waits when it is reached.
+	MaxOpen int
+	// MaxIdle bounds the number of idle resources kept for reuse, the others are destroyed when released.
+	// With 0, no resource is reused.
+	MaxIdle int
+	// MaxLifetime is how long a resource may be used after its creation, 0 meaning forever.
+	MaxLifetime time.Duration
+	// Clock tells the age of the resources, nil means the real clock.
+	Clock clock.Clock
+}
+
+// Pool keeps resources that are expensive to create, such as connections, for reuse.
+// Unlike sync.Pool, it bounds how many resources exist and destroys the ones it drops.
+type Pool[T any] struct {
+	opts PoolOptions[T]
+	sem  *Semaphore // nil without MaxOpen
+
+	// closing is done once Close is called, to wake up the callers waiting for a slot.
+	closing context.Context
+	stop    context.CancelFunc
+
+	mux    sync.Mutex
+	idle   []*Resource[T] // most recently released last
+	open   int
+	closed bool
+}
+
+// Resource is a resource handed out by a Pool. Give it back with Release, or Destroy it if it is broken.
+type Resource[T any] struct {
+	Value T
+
+	p       *Pool[T]
+	created time.Time
+	done    bool
+}
+
+// NewPool returns an empty pool, resources are created on demand.
+func NewPool[T any](opts PoolOptions[T]) *Pool[T] {
+	if opts.Create == nil {
+		panic("syncx: pool without Create")
+	}
+	if opts.MaxOpen < 0 || opts.MaxIdle < 0 {
+		panic("syncx: negative pool size")
+	}
+	opts.Clock = clock.Or(opts.Clock)
+	p := &Pool[T]{opts: opts}
+	p.closing, p.stop = context.WithCancel(context.Background())
+	if opts.MaxOpen > 0 {
+		p.sem = NewSemaphore(int64(opts.MaxOpen))
+	}
+	return p
+}
+
+// Get returns an idle resource if a valid one is left, or else a new one.
+// With MaxOpen, it waits for a resource to be released or destroyed until ctx is done or the pool is closed.
+func (p *Pool[T]) Get(ctx context.Context) (*Resource[T], error) {
+	if p.sem != nil && !p.sem.TryAcquire(1) {
+		if err := p.acquireSlot(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		p.mux.Lock()
+		if p.closed {
+			p.mux.Unlock()
+			p.releaseSlot()
+			return nil, ErrPoolClosed
+		}
+		n := len(p.idle)
+		if n == 0 {
+			p.open++
+			p.mux.Unlock()
+			break
+		}
+		r := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mux.Unlock()
+		if !p.expired(r) && (p.opts.Validate == nil || p.opts.Validate(r.Value)) {
+			r.done = false
+			return r, nil
+		}
+		p.destroy(r)
+	}
+
+	v, err := p.opts.Create(ctx)
+	if err != nil {
+		p.mux.Lock()
+		p.open--
+		p.mux.Unlock()
+		p.releaseSlot()
+		return nil, err
+	}
+	return &Resource[T]{Value: v, p: p, created: p.opts.Clock.Now()}, nil
+}
+
+func (p *Pool[T]) expired(r *Resource[T]) bool {
+	return p.opts.MaxLifetime > 0 && p.opts.Clock.Now().Sub(r.created) >= p.opts.MaxLifetime
+}
+
+// acquireSlot waits for a slot until ctx is done or the pool is closed.
+func (p *Pool[T]) acquireSlot(ctx context.Context) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stop := context.AfterFunc(p.closing, func() { cancel(ErrPoolClosed) })
+	defer stop()
+	if err := p.sem.Acquire(ctx, 1); err != nil {
+		if context.Cause(ctx) == ErrPoolClosed {
+			return ErrPoolClosed
+		}
+		return err
+	}
+	return nil
+}
+
+func (p *Pool[T]) releaseSlot() {
+	if p.sem != nil {
+		p.sem.Release(1)
+	}
+}
+
+// destroy gets rid of a resource, the caller releases its slot if it holds one.
+func (p *Pool[T]) destroy(r *Resource[T]) {
+	p.mux.Lock()
+	p.open--
+	p.mux.Unlock()
+	if p.opts.Destroy != nil {
+		p.opts.Destroy(r.Value)
+	}
+}
+
+// Release gives the resource back to the pool. It is destroyed instead if it expired,
+// the pool already has MaxIdle idle resources, or the pool is closed.
+func (r *Resource[T]) Release() {
+	p := r.p
+	if r.done {
+		panic("syncx: resource released twice")
+	}
+	r.done = true
+	p.mux.Lock()
+	keep := !p.closed && len(p.idle) < p.opts.MaxIdle && !p.expired(r)
+	if keep {
+		p.idle = append(p.idle, r)
+	}
+	p.mux.Unlock()
+	if !keep {
+		p.destroy(r)
+	}
+	p.releaseSlot()
+}
+
+// Destroy destroys the resource instead of giving it back, e.g. because it failed.
+func (r *Resource[T]) Destroy() {
+	if r.done {
+		panic("syncx: resource released twice")
+	}
+	r.done = true
+	r.p.destroy(r)
+	r.p.releaseSlot()
+}
+
+// PoolStats are the numbers of resources of a pool.
+type PoolStats struct {
+	Open int // in use or idle
+	Idle int
+}
+
+// Stats returns the numbers of resources of the pool.
+func (p *Pool[T]) Stats() PoolStats {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return PoolStats{Open: p.open, Idle: len(p.idle)}
+}
+
+// Close destroys the idle resources, the ones in use are destroyed when released.
+// The callers waiting in Get and the later ones get ErrPoolClosed.
+func (p *Pool[T]) Close() {
+	p.mux.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mux.Unlock()
+	p.stop()
+	for _, r := range idle {
+		p.destroy(r)
+	}
+}
@@ -0,0 +1,221 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-demo/clock"
+)
+
+var epoch = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// testPool returns a pool of ints numbered from 1 in order of creation, and the list of those destroyed.
+func testPool(opts PoolOptions[int]) (*Pool[int], func() []int) {
+	var mux sync.Mutex
+	created := 0
+	var destroyed []int
+	opts.Create = func(context.Context) (int, error) {
+		mux.Lock()
+		defer mux.Unlock()
+		created++
+		return created, nil
+	}
+	opts.Destroy = func(v int) {
+		mux.Lock()
+		defer mux.Unlock()
+		destroyed = append(destroyed, v)
+	}
+	return NewPool(opts), func() []int {
+		mux.Lock()
+		defer mux.Unlock()
+		return slices.Clone(destroyed)
+	}
+}
+
+func get(t *testing.T, p *Pool[int]) *Resource[int] {
+	t.Helper()
+	r, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestPoolMaxOpen(t *testing.T) {
+	p, _ := testPool(PoolOptions[int]{MaxOpen: 2, MaxIdle: 2})
+	r1, r2 := get(t, p), get(t, p)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get beyond MaxOpen: got %v, want DeadlineExceeded", err)
+	}
+
+	got := make(chan *Resource[int])
+	go func() {
+		r, _ := p.Get(context.Background())
+		got <- r
+	}()
+	waiting(p.sem, 1)
+	r1.Release()
+	// The waiter gets the released resource.
+	if r := <-got; r == nil || r.Value != 1 {
+		t.Errorf("waiter: got resource %d, want 1", r.Value)
+	}
+	if st := p.Stats(); st != (PoolStats{Open: 2, Idle: 0}) {
+		t.Errorf("got %+v", st)
+	}
+	// A destroyed resource frees its slot too.
+	r2.Destroy()
+	if r := get(t, p); r.Value != 3 {
+		t.Errorf("after Destroy: got resource %d, want a new one", r.Value)
+	}
+}
+
+func TestPoolMaxIdle(t *testing.T) {
+	tests := []struct {
+		maxIdle   int
+		idle      int
+		destroyed []int
+		next      int // value of the next Get
+	}{
+		{0, 0, []int{1, 2, 3}, 4},
+		{1, 1, []int{2, 3}, 1},
+		{3, 3, nil, 3}, // the most recently released
+	}
+	for _, tt := range tests {
+		p, destroyed := testPool(PoolOptions[int]{MaxIdle: tt.maxIdle})
+		rs := []*Resource[int]{get(t, p), get(t, p), get(t, p)}
+		for _, r := range rs {
+			r.Release()
+		}
+		if st := p.Stats(); st != (PoolStats{Open: tt.idle, Idle: tt.idle}) {
+			t.Errorf("MaxIdle %d: got %+v", tt.maxIdle, st)
+		}
+		if got := destroyed(); !slices.Equal(got, tt.destroyed) {
+			t.Errorf("MaxIdle %d: destroyed %v, want %v", tt.maxIdle, got, tt.destroyed)
+		}
+		if r := get(t, p); r.Value != tt.next {
+			t.Errorf("MaxIdle %d: got resource %d, want %d", tt.maxIdle, r.Value, tt.next)
+		}
+	}
+}
+
+func TestPoolMaxLifetime(t *testing.T) {
+	f := clock.NewFake(epoch)
+	p, destroyed := testPool(PoolOptions[int]{MaxIdle: 2, MaxLifetime: time.Minute, Clock: f})
+	r1 := get(t, p)
+	f.Advance(30 * time.Second)
+	r2 := get(t, p)
+	r1.Release()
+	r2.Release()
+	// Resource 1 expires while idle: Get drops it and hands out the next one.
+	f.Advance(30 * time.Second)
+	r := get(t, p)
+	r2 = get(t, p)
+	if r.Value != 2 || r2.Value != 3 {
+		t.Errorf("got resources %d and %d, want 2 then a new one", r.Value, r2.Value)
+	}
+	// Resource 2 expires while in use: Release destroys it.
+	f.Advance(30 * time.Second)
+	r.Release()
+	r2.Release()
+	if got := destroyed(); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("destroyed %v, want [1 2]", got)
+	}
+	if st := p.Stats(); st != (PoolStats{Open: 1, Idle: 1}) {
+		t.Errorf("got %+v", st)
+	}
+}
+
+func TestPoolValidate(t *testing.T) {
+	p, destroyed := testPool(PoolOptions[int]{MaxIdle: 2, Validate: func(v int) bool { return v%2 == 0 }})
+	r1, r2 := get(t, p), get(t, p)
+	r2.Release()
+	r1.Release()
+	// 1 is idle on top but invalid, 2 is handed out.
+	if r := get(t, p); r.Value != 2 {
+		t.Errorf("got resource %d, want 2", r.Value)
+	}
+	if got := destroyed(); !slices.Equal(got, []int{1}) {
+		t.Errorf("destroyed %v, want [1]", got)
+	}
+}
+
+func TestPoolCreateError(t *testing.T) {
+	boom := errors.New("boom")
+	p := NewPool(PoolOptions[int]{
+		Create:  func(context.Context) (int, error) { return 0, boom },
+		MaxOpen: 1,
+	})
+	for i := 0; i < 2; i++ { // the slot is given back each time
+		if _, err := p.Get(context.Background()); err != boom {
+			t.Fatalf("Get: got %v, want boom", err)
+		}
+	}
+	if st := p.Stats(); st.Open != 0 {
+		t.Errorf("got %+v", st)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	p, destroyed := testPool(PoolOptions[int]{MaxOpen: 1, MaxIdle: 1})
+	r := get(t, p)
+	// The pool is full: both callers wait, until Close turns them away.
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := p.Get(context.Background())
+			errs <- err
+		}()
+	}
+	waiting(p.sem, 2)
+	p.Close()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err != ErrPoolClosed {
+				t.Errorf("waiting Get: got %v, want ErrPoolClosed", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("a caller is still waiting after Close")
+		}
+	}
+	if _, err := p.Get(context.Background()); err != ErrPoolClosed {
+		t.Errorf("Get after Close: got %v, want ErrPoolClosed", err)
+	}
+	// The resource in use is destroyed when released.
+	if got := destroyed(); len(got) != 0 {
+		t.Errorf("destroyed %v before Release", got)
+	}
+	r.Release()
+	if got := destroyed(); !slices.Equal(got, []int{1}) {
+		t.Errorf("destroyed %v, want [1]", got)
+	}
+	if st := p.Stats(); st != (PoolStats{}) {
+		t.Errorf("got %+v", st)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("second Release didn't panic")
+		}
+	}()
+	r.Release()
+}
+
+func TestPoolCloseIdle(t *testing.T) {
+	p, destroyed := testPool(PoolOptions[int]{MaxIdle: 2})
+	r1, r2 := get(t, p), get(t, p)
+	r1.Release()
+	r2.Release()
+	p.Close()
+	if got := destroyed(); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("destroyed %v, want [1 2]", got)
+	}
+	if st := p.Stats(); st != (PoolStats{}) {
+		t.Errorf("got %+v", st)
+	}
+}
@@ -0,0 +1,106 @@
+package syncx
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrTooHeavy is returned when acquiring more than the size of a semaphore.
+var ErrTooHeavy = errors.New("syncx: weight exceeds semaphore size")
+
+// Semaphore bounds concurrent work by cost: each caller acquires a weight, e.g. the size of what it fetches,
+// and the total weight held at any time stays within the size of the semaphore.
+// Waiters are served in order, so a heavy caller is not starved by a flow of light ones.
+type Semaphore struct {
+	size int64
+
+	mux     sync.Mutex
+	cur     int64
+	waiters list.List // of *semaphoreWaiter
+}
+
+type semaphoreWaiter struct {
+	n     int64
+	ready chan struct{} // closed when the weight is acquired
+}
+
+// NewSemaphore returns a semaphore allowing a total weight of size.
+func NewSemaphore(size int64) *Semaphore {
+	if size <= 0 {
+		panic("syncx: non-positive semaphore size")
+	}
+	return &Semaphore{size: size}
+}
+
+// Acquire acquires a weight of n, blocking until it is available or ctx is done.
+// It returns ctx.Err() if ctx is done first, in which case nothing is acquired.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	s.mux.Lock()
+	if n > s.size {
+		s.mux.Unlock()
+		return ErrTooHeavy
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mux.Unlock()
+		return nil
+	}
+	w := &semaphoreWaiter{n: n, ready: make(chan struct{})}
+	e := s.waiters.PushBack(w)
+	s.mux.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mux.Lock()
+		select {
+		case <-w.ready:
+			// Acquired in the meantime, give it back.
+			s.cur -= n
+		default:
+			s.waiters.Remove(e)
+		}
+		// Either way the waiters behind may fit now.
+		s.notify()
+		s.mux.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire acquires a weight of n if it is available right now, and reports whether it did.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release releases a weight of n acquired before.
+func (s *Semaphore) Release(n int64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("syncx: semaphore released more than acquired")
+	}
+	s.notify()
+}
+
+// notify wakes up the waiters that fit, in order, s.mux must be held.
+func (s *Semaphore) notify() {
+	for e := s.waiters.Front(); e != nil; e = s.waiters.Front() {
+		w := e.Value.(*semaphoreWaiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(e)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,150 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waiting waits until n callers are queued on s.
+func waiting(s *Semaphore, n int) {
+	for {
+		s.mux.Lock()
+		k := s.waiters.Len()
+		s.mux.Unlock()
+		if k >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// acquireAsync starts Acquire(ctx, n) and returns the channel receiving its result.
+func acquireAsync(ctx context.Context, s *Semaphore, n int64) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- s.Acquire(ctx, n) }()
+	return done
+}
+
+// pending reports whether nothing was received from done yet.
+func pending(done <-chan error) bool {
+	select {
+	case <-done:
+		return false
+	case <-time.After(10 * time.Millisecond):
+		return true
+	}
+}
+
+func TestSemaphoreFIFO(t *testing.T) {
+	s := NewSemaphore(10)
+	s.Acquire(context.Background(), 8)
+	heavy := acquireAsync(context.Background(), s, 5)
+	waiting(s, 1)
+	// 2 are free, but the light callers queue behind the heavy one instead of starving it.
+	light := acquireAsync(context.Background(), s, 1)
+	waiting(s, 2)
+	if s.TryAcquire(1) {
+		t.Error("TryAcquire went before the waiters")
+	}
+	if !pending(heavy) || !pending(light) {
+		t.Fatal("a waiter got its weight with 2 free")
+	}
+	s.Release(8)
+	if err := <-heavy; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-light; err != nil {
+		t.Fatal(err)
+	}
+	if s.cur != 6 {
+		t.Errorf("weight held: got %d, want 6", s.cur)
+	}
+}
+
+func TestSemaphoreCancel(t *testing.T) {
+	s := NewSemaphore(10)
+	s.Acquire(context.Background(), 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	heavy := acquireAsync(ctx, s, 5)
+	waiting(s, 1)
+	light := acquireAsync(context.Background(), s, 2)
+	waiting(s, 2)
+	// The heavy waiter gives up: the light one behind it fits and gets its weight.
+	cancel()
+	if err := <-heavy; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Acquire: got %v", err)
+	}
+	if err := <-light; err != nil {
+		t.Fatal(err)
+	}
+	if s.cur != 10 || s.waiters.Len() != 0 {
+		t.Errorf("got %d held and %d waiters, want 10 and 0", s.cur, s.waiters.Len())
+	}
+
+	// A ctx already done still gets the weight if it is free right away.
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Release(10)
+	if err := s.Acquire(done, 4); err != nil {
+		t.Errorf("Acquire of a free weight: %v", err)
+	}
+}
+
+// Callers giving up while others release: the weight held stays within the size and nothing is lost.
+func TestSemaphoreCancelRace(t *testing.T) {
+	const size = 10
+	s := NewSemaphore(size)
+	rng := rand.New(rand.NewSource(1))
+	var held atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		n := rng.Int63n(size) + 1
+		timeout := time.Duration(rng.Intn(500)) * time.Microsecond
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if s.Acquire(ctx, n) != nil {
+				return
+			}
+			if h := held.Add(n); h > size {
+				t.Errorf("%d held, more than the size", h)
+			}
+			time.Sleep(100 * time.Microsecond)
+			held.Add(-n)
+			s.Release(n)
+		}()
+	}
+	wg.Wait()
+	if s.cur != 0 || s.waiters.Len() != 0 {
+		t.Errorf("got %d held and %d waiters, want 0 and 0", s.cur, s.waiters.Len())
+	}
+}
+
+func TestSemaphoreTooHeavy(t *testing.T) {
+	s := NewSemaphore(10)
+	// Doesn't wait forever, whatever the context.
+	if err := s.Acquire(context.Background(), 11); err != ErrTooHeavy {
+		t.Errorf("Acquire(11): got %v, want ErrTooHeavy", err)
+	}
+	if s.TryAcquire(11) {
+		t.Error("TryAcquire(11): got true")
+	}
+	if err := s.Acquire(context.Background(), 10); err != nil {
+		t.Errorf("Acquire(10): %v", err)
+	}
+	s.Release(10)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("releasing more than acquired didn't panic")
+		}
+	}()
+	s.Release(1)
+}
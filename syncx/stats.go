@@ -0,0 +1,214 @@
+package syncx
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// histogramBounds are the upper bounds of the histogram buckets, the last bucket holds everything above.
+var histogramBounds = []time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Histogram is a distribution of durations in power-of-ten buckets.
+type Histogram struct {
+	Bounds []time.Duration // upper bound of each bucket but the last
+	Counts []int64         // len(Bounds)+1 counters
+	Count  int64
+	Sum    time.Duration
+	Max    time.Duration
+}
+
+func newHistogram() Histogram {
+	return Histogram{Bounds: histogramBounds, Counts: make([]int64, len(histogramBounds)+1)}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := sort.Search(len(h.Bounds), func(i int) bool { return d <= h.Bounds[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]int64(nil), h.Counts...)
+	return h
+}
+
+// Mean returns the average duration, 0 if nothing was observed.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// String draws the histogram, one line per bucket.
+func (h Histogram) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "count=%d mean=%v max=%v\n", h.Count, h.Mean(), h.Max)
+	for i, n := range h.Counts {
+		label := "+Inf"
+		if i < len(h.Bounds) {
+			label = "<=" + h.Bounds[i].String()
+		}
+		bar := 0
+		if h.Count > 0 {
+			bar = int(40 * n / h.Count)
+		}
+		fmt.Fprintf(&b, "%8s %8d %s\n", label, n, strings.Repeat("#", bar))
+	}
+	return b.String()
+}
+
+// Hold is one long hold of a lock and where the lock was taken.
+type Hold struct {
+	Duration time.Duration
+	Stack    []string // "function file:line" frames, innermost first, nil unless RecordStacks is on
+}
+
+// Stats are the contention statistics of an instrumented lock.
+type Stats struct {
+	Acquisitions int64 // successful Lock (or RLock) calls
+	Contentions  int64 // acquisitions that had to wait for another holder
+	Wait         Histogram
+	Hold         Histogram // write (exclusive) holds only
+	Longest      []Hold    // longest holds, longest first
+}
+
+// String reports the statistics in a human readable form.
+func (s Stats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "acquisitions=%d contentions=%d\n", s.Acquisitions, s.Contentions)
+	fmt.Fprintf(&b, "wait: %v", s.Wait)
+	fmt.Fprintf(&b, "hold: %v", s.Hold)
+	for _, h := range s.Longest {
+		fmt.Fprintf(&b, "held %v at\n", h.Duration)
+		for _, frame := range h.Stack {
+			fmt.Fprintf(&b, "\t%s\n", frame)
+		}
+	}
+	return b.String()
+}
+
+// maxLongest is how many of the longest holds are kept.
+const maxLongest = 5
+
+// maxDepth is how many frames of the locker's stack are recorded.
+const maxDepth = 8
+
+// recorder accumulates the Stats of one lock.
+type recorder struct {
+	mux   sync.Mutex
+	stats Stats
+	pcs   [][]uintptr // stacks of stats.Longest, resolved lazily
+}
+
+func (r *recorder) init() {
+	if r.stats.Wait.Counts == nil {
+		r.stats.Wait = newHistogram()
+		r.stats.Hold = newHistogram()
+	}
+}
+
+func (r *recorder) acquired(wait time.Duration, contended bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.init()
+	r.stats.Acquisitions++
+	if contended {
+		r.stats.Contentions++
+	}
+	r.stats.Wait.observe(wait)
+}
+
+func (r *recorder) released(hold time.Duration, pcs []uintptr) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.init()
+	r.stats.Hold.observe(hold)
+	longest := r.stats.Longest
+	if len(longest) == maxLongest && hold <= longest[len(longest)-1].Duration {
+		return
+	}
+	i := sort.Search(len(longest), func(i int) bool { return longest[i].Duration < hold })
+	r.stats.Longest = append(longest, Hold{})
+	r.pcs = append(r.pcs, nil)
+	copy(r.stats.Longest[i+1:], r.stats.Longest[i:])
+	copy(r.pcs[i+1:], r.pcs[i:])
+	r.stats.Longest[i] = Hold{Duration: hold}
+	r.pcs[i] = pcs
+	if len(r.stats.Longest) > maxLongest {
+		r.stats.Longest = r.stats.Longest[:maxLongest]
+		r.pcs = r.pcs[:maxLongest]
+	}
+}
+
+func (r *recorder) snapshot() Stats {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.init()
+	s := r.stats
+	s.Wait = s.Wait.clone()
+	s.Hold = s.Hold.clone()
+	s.Longest = make([]Hold, len(r.stats.Longest))
+	for i, h := range r.stats.Longest {
+		s.Longest[i] = Hold{Duration: h.Duration, Stack: frames(r.pcs[i])}
+	}
+	return s
+}
+
+func (r *recorder) reset() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.stats = Stats{}
+	r.pcs = nil
+	r.init()
+}
+
+// stacks is set by RecordStacks.
+var stacks atomic.Bool
+
+// RecordStacks turns on or off the recording of where the instrumented locks are taken, for Stats.Longest.
+// It is off by default: walking the stack on every Lock costs more than the lock itself, turn it on to debug.
+func RecordStacks(on bool) {
+	stacks.Store(on)
+}
+
+// callers records the stack of the goroutine taking a lock, skipping the syncx frames, nil if RecordStacks is off.
+func callers() []uintptr {
+	if !stacks.Load() {
+		return nil
+	}
+	pcs := make([]uintptr, maxDepth)
+	return pcs[:runtime.Callers(3, pcs)]
+}
+
+func frames(pcs []uintptr) []string {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var stack []string
+	it := runtime.CallersFrames(pcs)
+	for {
+		f, more := it.Next()
+		stack = append(stack, fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line))
+		if !more {
+			return stack
+		}
+	}
+}
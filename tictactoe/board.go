@@ -0,0 +1,179 @@
+// Package tictactoe is a tic-tac-toe engine: the `[][]string` board of main/6-array.go
+// made into a game that checks the moves, whose turn it is, and who won.
+package tictactoe
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Mark is the content of a cell.
+type Mark byte
+
+const (
+	Empty Mark = iota
+	X
+	O
+)
+
+// String returns "X", "O", or "_" for an empty cell, as in main/6-array.go.
+func (m Mark) String() string {
+	switch m {
+	case X:
+		return "X"
+	case O:
+		return "O"
+	}
+	return "_"
+}
+
+// Other returns the mark of the other player.
+func (m Mark) Other() Mark {
+	switch m {
+	case X:
+		return O
+	case O:
+		return X
+	}
+	return Empty
+}
+
+// Size is the number of rows and columns of the board.
+const Size = 3
+
+// Board is a grid of cells, Board[row][col]. The zero value is an empty board.
+type Board [Size][Size]Mark
+
+// lines are the rows, columns and diagonals, as lists of [row, col] cells.
+var lines = func() [][Size][2]int {
+	var ls [][Size][2]int
+	var diag, anti [Size][2]int
+	for i := 0; i < Size; i++ {
+		var row, col [Size][2]int
+		for j := 0; j < Size; j++ {
+			row[j] = [2]int{i, j}
+			col[j] = [2]int{j, i}
+		}
+		ls = append(ls, row, col)
+		diag[i] = [2]int{i, i}
+		anti[i] = [2]int{i, Size - 1 - i}
+	}
+	return append(ls, diag, anti)
+}()
+
+// Winner returns the mark filling a whole row, column or diagonal, Empty if there is none.
+// On a board that can't be reached in a game both marks may do, X is returned then.
+func (b Board) Winner() Mark {
+	for _, m := range []Mark{X, O} {
+		if b.wins(m) {
+			return m
+		}
+	}
+	return Empty
+}
+
+// Count returns the number of cells holding m.
+func (b Board) Count(m Mark) int {
+	n := 0
+	for _, row := range b {
+		for _, c := range row {
+			if c == m {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Full reports whether no cell is empty.
+func (b Board) Full() bool {
+	return b.Count(Empty) == 0
+}
+
+// String returns the board one row per line, the cells separated by spaces:
+//
+//	X _ X
+//	O _ X
+//	_ _ O
+func (b Board) String() string {
+	rows := make([]string, Size)
+	for i, row := range b {
+		cells := make([]string, Size)
+		for j, c := range row {
+			cells[j] = c.String()
+		}
+		rows[i] = strings.Join(cells, " ")
+	}
+	return strings.Join(rows, "\n")
+}
+
+// ErrBadBoard is returned for a board that can't be parsed or can't be reached in a game.
+var ErrBadBoard = errors.New("tictactoe: bad board")
+
+// ParseBoard parses a board written as by String. Spaces and line breaks are optional,
+// "X_X/O_X/__O" with '/' between rows is fine too.
+func ParseBoard(s string) (Board, error) {
+	var b Board
+	i := 0
+	for _, r := range s {
+		var m Mark
+		switch r {
+		case ' ', '\t', '\n', '\r', '/':
+			continue
+		case 'X', 'x':
+			m = X
+		case 'O', 'o':
+			m = O
+		case '_', '.', '-':
+			m = Empty
+		default:
+			return Board{}, fmt.Errorf("%w: unexpected %q", ErrBadBoard, r)
+		}
+		if i == Size*Size {
+			return Board{}, fmt.Errorf("%w: more than %d cells", ErrBadBoard, Size*Size)
+		}
+		b[i/Size][i%Size] = m
+		i++
+	}
+	if i != Size*Size {
+		return Board{}, fmt.Errorf("%w: %d cells instead of %d", ErrBadBoard, i, Size*Size)
+	}
+	return b, nil
+}
+
+// Validate checks that the board can be reached in a game where X plays first:
+// X has as many marks as O or one more, and the game stopped at the first win.
+func (b Board) Validate() error {
+	x, o := b.Count(X), b.Count(O)
+	if x != o && x != o+1 {
+		return fmt.Errorf("%w: %d X for %d O", ErrBadBoard, x, o)
+	}
+	xWins, oWins := b.wins(X), b.wins(O)
+	switch {
+	case xWins && oWins:
+		return fmt.Errorf("%w: both players won", ErrBadBoard)
+	case xWins && x != o+1:
+		return fmt.Errorf("%w: O played after X won", ErrBadBoard)
+	case oWins && x != o:
+		return fmt.Errorf("%w: X played after O won", ErrBadBoard)
+	}
+	return nil
+}
+
+// wins reports whether m fills a line.
+func (b Board) wins(m Mark) bool {
+	for _, l := range lines {
+		all := true
+		for _, c := range l {
+			if b[c[0]][c[1]] != m {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package tictactoe
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWinner(t *testing.T) {
+	tests := []struct {
+		board string
+		want  Mark
+	}{
+		{"___/___/___", Empty},
+		{"XXX/OO_/___", X},
+		{"XO_/XO_/X__", X},
+		{"OXX/XO_/__O", O},
+		{"XXO/XO_/O__", O},
+		{"XOX/XOO/OXX", Empty},
+		{"XX_/OO_/___", Empty},
+	}
+	for _, tt := range tests {
+		b, err := ParseBoard(tt.board)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := b.Winner(); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.board, got, tt.want)
+		}
+	}
+}
+
+func TestParseBoard(t *testing.T) {
+	want := Board{{X, Empty, X}, {O, Empty, X}, {Empty, Empty, O}}
+	for _, s := range []string{want.String(), "X_X/O_X/__O", "x.x o.x --o", "X_XO_X__O"} {
+		b, err := ParseBoard(s)
+		if err != nil || b != want {
+			t.Errorf("%q: got %v, %v, want\n%v", s, b, err, want)
+		}
+	}
+	for _, s := range []string{"", "X_X/O_X/__", "X_X/O_X/__O/_", "X_X/O?X/__O"} {
+		if _, err := ParseBoard(s); !errors.Is(err, ErrBadBoard) {
+			t.Errorf("%q: got %v, want ErrBadBoard", s, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		board string
+		ok    bool
+	}{
+		{"___/___/___", true},
+		{"X__/___/___", true},
+		{"XO_/___/___", true},
+		{"O__/___/___", false}, // O played first
+		{"XX_/___/___", false}, // X played twice
+		{"XXX/OO_/___", true},
+		{"XXX/OOO/___", false}, // both won
+		{"XXX/OO_/O__", false}, // O played after X won
+		{"OOO/XX_/X__", true},
+		{"OOO/XX_/XX_", false}, // X played after O won
+	}
+	for _, tt := range tests {
+		b, err := ParseBoard(tt.board)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Validate(); (err == nil) != tt.ok || err != nil && !errors.Is(err, ErrBadBoard) {
+			t.Errorf("%s: got %v", tt.board, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package tictactoe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Move is a mark put in a cell.
+type Move struct {
+	Row, Col int
+	Mark     Mark
+}
+
+// Status tells whether a game is over and how.
+type Status int
+
+const (
+	InProgress Status = iota
+	XWon
+	OWon
+	Draw
+)
+
+func (s Status) String() string {
+	switch s {
+	case InProgress:
+		return "in progress"
+	case XWon:
+		return "X won"
+	case OWon:
+		return "O won"
+	case Draw:
+		return "draw"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+var (
+	// ErrOutOfBounds is returned for a move outside the board.
+	ErrOutOfBounds = errors.New("tictactoe: cell out of the board")
+	// ErrOccupied is returned for a move on a cell that is not empty.
+	ErrOccupied = errors.New("tictactoe: cell occupied")
+	// ErrGameOver is returned for a move once the game is won or drawn.
+	ErrGameOver = errors.New("tictactoe: game over")
+	// ErrNoMoves is returned by Undo when there is no move to take back.
+	ErrNoMoves = errors.New("tictactoe: no move to undo")
+)
+
+// Game is a game of tic-tac-toe: the board, whose turn it is, and the moves played so far.
+type Game struct {
+	start Board // the position the game started from
+	board Board
+	moves []Move
+}
+
+// New returns a game on an empty board, X plays first.
+func New() *Game {
+	return &Game{}
+}
+
+// FromBoard returns a game continuing from the position b, which must be reachable in a game.
+// The moves that led to b are unknown, so they can't be undone.
+func FromBoard(b Board) (*Game, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return &Game{start: b, board: b}, nil
+}
+
+// Board returns the current position, a copy that the caller may change freely.
+func (g *Game) Board() Board {
+	return g.board
+}
+
+// Turn returns the mark of the player to move, Empty if the game is over.
+func (g *Game) Turn() Mark {
+	if g.Status() != InProgress {
+		return Empty
+	}
+	if g.board.Count(X) > g.board.Count(O) {
+		return O
+	}
+	return X
+}
+
+// Status tells whether the game is over and how.
+func (g *Game) Status() Status {
+	switch g.board.Winner() {
+	case X:
+		return XWon
+	case O:
+		return OWon
+	}
+	if g.board.Full() {
+		return Draw
+	}
+	return InProgress
+}
+
+// Check returns the error Play would return for a move on cell (row, col), nil if the move is valid.
+func (g *Game) Check(row, col int) error {
+	if g.Status() != InProgress {
+		return ErrGameOver
+	}
+	if row < 0 || row >= Size || col < 0 || col >= Size {
+		return fmt.Errorf("%w: (%d, %d)", ErrOutOfBounds, row, col)
+	}
+	if m := g.board[row][col]; m != Empty {
+		return fmt.Errorf("%w: (%d, %d) holds %v", ErrOccupied, row, col, m)
+	}
+	return nil
+}
+
+// Play puts the mark of the player to move on cell (row, col), and returns the new status of the game.
+func (g *Game) Play(row, col int) (Status, error) {
+	if err := g.Check(row, col); err != nil {
+		return g.Status(), err
+	}
+	m := g.Turn()
+	g.board[row][col] = m
+	g.moves = append(g.moves, Move{row, col, m})
+	return g.Status(), nil
+}
+
+// Undo takes back the last move.
+func (g *Game) Undo() (Move, error) {
+	if len(g.moves) == 0 {
+		return Move{}, ErrNoMoves
+	}
+	last := g.moves[len(g.moves)-1]
+	g.moves = g.moves[:len(g.moves)-1]
+	g.board[last.Row][last.Col] = Empty
+	return last, nil
+}
+
+// Moves returns the moves played so far, in order.
+func (g *Game) Moves() []Move {
+	return append([]Move(nil), g.moves...)
+}
+
+// Free returns the empty cells, as the moves the player to move can play. It is empty once the game is over.
+func (g *Game) Free() []Move {
+	turn := g.Turn()
+	if turn == Empty {
+		return nil
+	}
+	var free []Move
+	for i, row := range g.board {
+		for j, c := range row {
+			if c == Empty {
+				free = append(free, Move{i, j, turn})
+			}
+		}
+	}
+	return free
+}
+
+// String returns the board followed by the status, or whose turn it is.
+func (g *Game) String() string {
+	if s := g.Status(); s != InProgress {
+		return fmt.Sprintf("%v\n%v", g.board, s)
+	}
+	return fmt.Sprintf("%v\n%v to play", g.board, g.Turn())
+}
+
+// gameJSON is the serialized form of a Game: the starting position and the moves,
+// plus the current board and status for whoever reads the JSON.
+type gameJSON struct {
+	Start  string   `json:"start,omitempty"`
+	Moves  [][2]int `json:"moves"`
+	Board  string   `json:"board"`
+	Status string   `json:"status"`
+}
+
+// compact writes a board on one line, rows separated by '/'.
+func compact(b Board) string {
+	s := make([]byte, 0, Size*(Size+1))
+	for i, row := range b {
+		if i > 0 {
+			s = append(s, '/')
+		}
+		for _, c := range row {
+			s = append(s, c.String()...)
+		}
+	}
+	return string(s)
+}
+
+// MarshalJSON encodes the game so that it can be saved and resumed, undo history included:
+//
+//	{"moves":[[0,0],[2,2],[1,2]],"board":"X__/__X/__O","status":"in progress"}
+func (g *Game) MarshalJSON() ([]byte, error) {
+	j := gameJSON{Moves: make([][2]int, len(g.moves)), Board: compact(g.board), Status: g.Status().String()}
+	if g.start != (Board{}) {
+		j.Start = compact(g.start)
+	}
+	for i, m := range g.moves {
+		j.Moves[i] = [2]int{m.Row, m.Col}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes a game encoded by MarshalJSON, replaying its moves: an invalid move is an error.
+// The board field, if present, must match the position the moves lead to.
+func (g *Game) UnmarshalJSON(data []byte) error {
+	var j gameJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	ng := New()
+	if j.Start != "" {
+		b, err := ParseBoard(j.Start)
+		if err != nil {
+			return err
+		}
+		if ng, err = FromBoard(b); err != nil {
+			return err
+		}
+	}
+	for i, m := range j.Moves {
+		if _, err := ng.Play(m[0], m[1]); err != nil {
+			return fmt.Errorf("tictactoe: move %d: %w", i+1, err)
+		}
+	}
+	if j.Board != "" {
+		b, err := ParseBoard(j.Board)
+		if err != nil {
+			return err
+		}
+		if b != ng.board {
+			return fmt.Errorf("%w: the moves lead to %s, not %s", ErrBadBoard, compact(ng.board), j.Board)
+		}
+	}
+	*g = *ng
+	return nil
+}
@@ -0,0 +1,153 @@
+package tictactoe
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// play plays the moves, given as [row, col], failing the test on an invalid one.
+func play(t *testing.T, g *Game, moves ...[2]int) Status {
+	t.Helper()
+	var s Status
+	for _, m := range moves {
+		var err error
+		if s, err = g.Play(m[0], m[1]); err != nil {
+			t.Fatalf("%v: %v", m, err)
+		}
+	}
+	return s
+}
+
+func TestPlay(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves [][2]int
+		want  Status
+		board string
+	}{
+		{"row", [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}}, XWon, "XXX/OO_/___"},
+		{"column", [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {2, 2}, {2, 1}}, OWon, "XO_/XO_/_OX"},
+		{"diagonal", [][2]int{{0, 0}, {0, 1}, {1, 1}, {0, 2}, {2, 2}}, XWon, "XOO/_X_/__X"},
+		{"anti-diagonal", [][2]int{{0, 0}, {0, 2}, {0, 1}, {1, 1}, {2, 2}, {2, 0}}, OWon, "XXO/_O_/O_X"},
+		{"draw", [][2]int{{0, 0}, {1, 1}, {2, 2}, {0, 1}, {2, 1}, {2, 0}, {0, 2}, {1, 2}, {1, 0}}, Draw, "XOX/XOO/OXX"},
+		{"in progress", [][2]int{{1, 1}, {0, 0}}, InProgress, "O__/_X_/___"},
+	}
+	for _, tt := range tests {
+		g := New()
+		if s := play(t, g, tt.moves...); s != tt.want || g.Status() != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, s, tt.want)
+		}
+		if got := compact(g.Board()); got != tt.board {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.board)
+		}
+		if tt.want != InProgress && (g.Turn() != Empty || g.Free() != nil) {
+			t.Errorf("%s: game over but %v to play, free %v", tt.name, g.Turn(), g.Free())
+		}
+	}
+}
+
+func TestIllegalMoves(t *testing.T) {
+	g := New()
+	play(t, g, [2]int{1, 1})
+	tests := []struct {
+		row, col int
+		want     error
+	}{
+		{-1, 0, ErrOutOfBounds},
+		{0, 3, ErrOutOfBounds},
+		{1, 1, ErrOccupied},
+	}
+	for _, tt := range tests {
+		if s, err := g.Play(tt.row, tt.col); !errors.Is(err, tt.want) || s != InProgress {
+			t.Errorf("(%d, %d): got %v, %v, want %v", tt.row, tt.col, s, err, tt.want)
+		}
+	}
+	// Illegal moves change nothing, O is still to play.
+	if g.Turn() != O || len(g.Moves()) != 1 || len(g.Free()) != 8 {
+		t.Errorf("got %v to play after %v", g.Turn(), g.Moves())
+	}
+
+	play(t, g, [2]int{0, 0}, [2]int{0, 1}, [2]int{2, 2}, [2]int{2, 1})
+	if _, err := g.Play(2, 0); !errors.Is(err, ErrGameOver) {
+		t.Errorf("after X won: got %v, want ErrGameOver", err)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	g := New()
+	if _, err := g.Undo(); err != ErrNoMoves {
+		t.Errorf("empty game: got %v, want ErrNoMoves", err)
+	}
+	play(t, g, [2]int{0, 0}, [2]int{1, 0}, [2]int{0, 1}, [2]int{1, 1}, [2]int{0, 2})
+	if m, err := g.Undo(); err != nil || m != (Move{0, 2, X}) {
+		t.Errorf("got %v, %v", m, err)
+	}
+	if g.Status() != InProgress || g.Turn() != X || compact(g.Board()) != "XX_/OO_/___" {
+		t.Errorf("after Undo: got\n%v", g)
+	}
+
+	// The moves before FromBoard can't be undone.
+	b, _ := ParseBoard("XX_/OO_/___")
+	g, err := FromBoard(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	play(t, g, [2]int{2, 2})
+	g.Undo()
+	if _, err := g.Undo(); err != ErrNoMoves {
+		t.Errorf("FromBoard: got %v, want ErrNoMoves", err)
+	}
+	if _, err := FromBoard(Board{{O}}); !errors.Is(err, ErrBadBoard) {
+		t.Errorf("FromBoard: got %v, want ErrBadBoard", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	g := New()
+	play(t, g, [2]int{0, 0}, [2]int{2, 2}, [2]int{1, 2})
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"moves":[[0,0],[2,2],[1,2]],"board":"X__/__X/__O","status":"in progress"}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	var g2 Game
+	if err := json.Unmarshal(data, &g2); err != nil {
+		t.Fatal(err)
+	}
+	if g2.Board() != g.Board() || !slices.Equal(g2.Moves(), g.Moves()) {
+		t.Errorf("got\n%v\nwant\n%v", &g2, g)
+	}
+
+	// A game resumed from a position keeps it as its start.
+	b, _ := ParseBoard("XX_/OO_/___")
+	g, _ = FromBoard(b)
+	play(t, g, [2]int{0, 2})
+	data, _ = json.Marshal(g)
+	if want := `{"start":"XX_/OO_/___","moves":[[0,2]],"board":"XXX/OO_/___","status":"X won"}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	var g3 Game
+	if err := json.Unmarshal(data, &g3); err != nil || g3.Status() != XWon || g3.start != b {
+		t.Errorf("got %v, %v", &g3, err)
+	}
+
+	bad := []struct {
+		json string
+		want error
+	}{
+		{`{"moves":[[0,0],[0,0]]}`, ErrOccupied},
+		{`{"moves":[[0,3]]}`, ErrOutOfBounds},
+		{`{"start":"XXX/OO_/___","moves":[[2,2]]}`, ErrGameOver},
+		{`{"start":"OO_/___/___","moves":[]}`, ErrBadBoard},
+		{`{"moves":[[0,0]],"board":"_X_/___/___"}`, ErrBadBoard},
+	}
+	for _, tt := range bad {
+		if err := json.Unmarshal([]byte(tt.json), new(Game)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.json, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package trace
+
+import (
+	"cmp"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// snapshot returns the lanes, the records and the end of the timeline.
+// The records are ordered by start, a span before the shorter spans starting with it,
+// so drawing them in order draws nested spans over the spans containing them.
+func (t *Tracer) snapshot() ([]string, []Record, time.Duration) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	lanes := slices.Clone(t.lanes)
+	records := slices.Clone(t.records)
+	end := t.clock.Now().Sub(t.start)
+	for i, r := range records {
+		// Spans begun before a Reset may start before 0 and be in forgotten lanes.
+		records[i].Start = max(r.Start, 0)
+		if !slices.Contains(lanes, r.Lane) {
+			lanes = append(lanes, r.Lane)
+		}
+		end = max(end, r.End)
+	}
+	slices.SortStableFunc(records, func(a, b Record) int {
+		return cmp.Or(cmp.Compare(a.Start, b.Start), cmp.Compare(b.End, a.End))
+	})
+	return lanes, records, end
+}
+
+// WriteText draws the timeline in text, width characters wide, one line per lane:
+// each span is drawn with a letter given in the legend below, each event with a '*'.
+//
+//	      0s                  500ms
+//	hello |ssss*sssss*ssss*sssss*|
+//	world |sssss*ssss*sssss*ssss*|
+//	s = sleep
+func (t *Tracer) WriteText(w io.Writer, width int) error {
+	if width < 10 {
+		width = 10
+	}
+	lanes, records, end := t.snapshot()
+	if end <= 0 {
+		end = 1
+	}
+	col := func(d time.Duration) int {
+		return min(int(int64(d)*int64(width)/int64(end)), width-1)
+	}
+
+	// Give each span name a letter, its first one if still free.
+	letters := map[string]byte{}
+	used := map[byte]bool{}
+	var names []string
+	for _, r := range records {
+		if r.Event {
+			continue
+		}
+		if _, ok := letters[r.Name]; ok {
+			continue
+		}
+		letter := byte('?')
+		candidates := strings.ToLower(r.Name) + "abcdefghijklmnopqrstuvwxyz"
+		for i := 0; i < len(candidates); i++ {
+			if c := candidates[i]; c >= 'a' && c <= 'z' && !used[c] {
+				letter = c
+				break
+			}
+		}
+		used[letter] = true
+		letters[r.Name] = letter
+		names = append(names, r.Name)
+	}
+
+	pad := 0
+	for _, l := range lanes {
+		pad = max(pad, len(l))
+	}
+	var b strings.Builder
+	endLabel := end.Round(time.Millisecond).String()
+	fmt.Fprintf(&b, "%*s %-*s%s\n", pad, "", width+2-len(endLabel), "0s", endLabel)
+	for _, l := range lanes {
+		row := []byte(strings.Repeat(".", width))
+		for _, r := range records {
+			if r.Lane != l || r.Event {
+				continue
+			}
+			for i := col(r.Start); i <= col(r.End); i++ {
+				row[i] = letters[r.Name]
+			}
+		}
+		// Events last so spans don't hide them.
+		for _, r := range records {
+			if r.Lane == l && r.Event {
+				row[col(r.Start)] = '*'
+			}
+		}
+		fmt.Fprintf(&b, "%-*s |%s|\n", pad, l, row)
+	}
+	for _, n := range names {
+		fmt.Fprintf(&b, "%c = %s\n", letters[n], n)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHTML writes a self-contained HTML page drawing the timeline as swimlanes, one per lane.
+// Hovering a span or an event shows its name and times.
+func (t *Tracer) WriteHTML(w io.Writer, title string) error {
+	lanes, records, end := t.snapshot()
+	if end <= 0 {
+		end = 1
+	}
+	pct := func(d time.Duration) float64 {
+		return float64(d) * 100 / float64(end)
+	}
+	type bar struct {
+		Left, Width float64
+		Color       template.CSS
+		Title       string
+		Event       bool
+	}
+	type lane struct {
+		Name string
+		Bars []bar
+	}
+	data := struct {
+		Title string
+		End   string
+		Lanes []lane
+	}{Title: title, End: end.Round(time.Microsecond).String()}
+	for _, l := range lanes {
+		ln := lane{Name: l}
+		for _, r := range records {
+			if r.Lane != l {
+				continue
+			}
+			b := bar{Left: pct(r.Start), Width: pct(r.End - r.Start), Color: color(r.Name), Event: r.Event}
+			if r.Event {
+				b.Title = fmt.Sprintf("%s at %v", r.Name, r.Start)
+			} else {
+				b.Title = fmt.Sprintf("%s from %v to %v (%v)", r.Name, r.Start, r.End, r.End-r.Start)
+			}
+			ln.Bars = append(ln.Bars, b)
+		}
+		data.Lanes = append(data.Lanes, ln)
+	}
+	return htmlTemplate.Execute(w, data)
+}
+
+// color returns a color for a span name, the same for every span of that name.
+func color(name string) template.CSS {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return template.CSS(fmt.Sprintf("hsl(%d, 60%%, 60%%)", h.Sum32()%360))
+}
+
+var htmlTemplate = template.Must(template.New("trace").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.lane { display: flex; align-items: center; border-bottom: 1px solid #ddd; }
+.name { width: 10em; flex-shrink: 0; font-family: monospace; }
+.track { position: relative; flex-grow: 1; height: 2em; }
+.span { position: absolute; top: 0.4em; height: 1.2em; min-width: 1px; border-radius: 2px; opacity: 0.8; }
+.event { position: absolute; top: 0.1em; height: 1.8em; width: 2px; background: #000; }
+.axis { display: flex; justify-content: space-between; margin-left: 10em; color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="axis"><span>0s</span><span>{{.End}}</span></div>
+{{range .Lanes}}<div class="lane"><div class="name">{{.Name}}</div><div class="track">
+{{- range .Bars}}
+{{if .Event}}<div class="event" style="left: {{printf "%.3f" .Left}}%" title="{{.Title}}"></div>
+{{- else}}<div class="span" style="left: {{printf "%.3f" .Left}}%; width: {{printf "%.3f" .Width}}%; background: {{.Color}}" title="{{.Title}}"></div>
+{{- end}}
+{{- end}}
+</div></div>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,109 @@
+package trace
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang-demo/clock"
+)
+
+// testTracer returns a 10s trace: in lane main, "fetch" for the whole time with "parse" nested in it
+// from 2s to 6s and the event "done" at 6s; in lane worker, "write" from 2s to 5s.
+func testTracer() *Tracer {
+	clk := clock.NewFake(t0)
+	tr := New(clk)
+	tr.Label("main")
+	fetch := tr.Begin("fetch")
+	clk.Advance(2 * time.Second)
+	parse := tr.Begin("parse")
+	spans := make(chan *Span)
+	go func() {
+		tr.Label("worker")
+		spans <- tr.Begin("write")
+	}()
+	write := <-spans
+	clk.Advance(3 * time.Second)
+	write.End()
+	clk.Advance(time.Second)
+	tr.Event("done")
+	parse.End()
+	clk.Advance(4 * time.Second)
+	fetch.End()
+	return tr
+}
+
+func TestWriteText(t *testing.T) {
+	var b strings.Builder
+	if err := testTracer().WriteText(&b, 10); err != nil {
+		t.Fatal(err)
+	}
+	// The nested span is drawn over the span containing it, the event over both.
+	want := `       0s       10s
+main   |ffpppp*fff|
+worker |..wwww....|
+f = fetch
+p = parse
+w = write
+`
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Letters are taken from the name while free, and spans begun before a Reset are clipped at 0.
+	clk := clock.NewFake(t0)
+	tr := New(clk)
+	tr.Label("main")
+	before := tr.Begin("sleep")
+	clk.Advance(time.Second)
+	tr.Reset()
+	s := tr.Begin("send")
+	clk.Advance(time.Second)
+	s.End()
+	clk.Advance(time.Second)
+	before.End()
+	b.Reset()
+	tr.WriteText(&b, 10)
+	want = `     0s        2s
+main |eeeeeessss|
+s = sleep
+e = send
+`
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var b strings.Builder
+	if err := testTracer().WriteHTML(&b, "<fetch>"); err != nil {
+		t.Fatal(err)
+	}
+	html := b.String()
+	for _, want := range []string{
+		"<title>&lt;fetch&gt;</title>",
+		`<span>10s</span>`,
+		`<div class="name">main</div>`,
+		`<div class="name">worker</div>`,
+		`left: 20.000%; width: 30.000%; background: ` + string(color("write")),
+		`title="write from 2s to 5s (3s)"`,
+		`<div class="event" style="left: 60.000%" title="done at 6s">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("%q missing from\n%s", want, html)
+		}
+	}
+	// The nested span comes after the span containing it, so it is on top.
+	if i, j := strings.Index(html, `title="fetch `), strings.Index(html, `title="parse `); i < 0 || j < i {
+		t.Errorf("parse not after fetch in\n%s", html)
+	}
+	if n := strings.Count(html, `class="span"`); n != 3 {
+		t.Errorf("got %d spans, want 3", n)
+	}
+}
+
+func TestColor(t *testing.T) {
+	if color("sleep") != color("sleep") || color("sleep") == color("send") {
+		t.Errorf("got %s, %s for sleep and %s for send", color("sleep"), color("sleep"), color("send"))
+	}
+}
@@ -0,0 +1,139 @@
+// Package trace records what goroutines do over time, as spans (something with a start and an end)
+// and events (something that happens at one point in time), and draws them as a timeline
+// with one lane per goroutine, to see how the goroutines of a demo interleave.
+package trace
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang-demo/clock"
+)
+
+// Record is a span or an event, its times are offsets from the creation of the Tracer.
+type Record struct {
+	Lane  string
+	Name  string
+	Start time.Duration
+	End   time.Duration // equal to Start for an event
+	Event bool
+}
+
+// Tracer collects records. It is safe to use concurrently.
+type Tracer struct {
+	clock clock.Clock
+	start time.Time
+
+	mux     sync.Mutex
+	labels  map[uint64]string // goroutine id -> lane
+	lanes   []string          // in order of appearance
+	records []Record
+}
+
+// New returns a tracer whose time starts now. A nil clock means the real clock.
+func New(c clock.Clock) *Tracer {
+	c = clock.Or(c)
+	return &Tracer{clock: c, start: c.Now(), labels: make(map[uint64]string)}
+}
+
+// goid returns the id of the calling goroutine, read from the header of its stack: "goroutine 18 [running]:".
+// The runtime doesn't expose it on purpose, it is only used to name lanes.
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// Label names the lane of the calling goroutine, "g<id>" by default.
+// Goroutines given the same label share a lane.
+func (t *Tracer) Label(name string) {
+	id := goid()
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.labels[id] = name
+}
+
+// lane returns the lane of the calling goroutine, t.mux must be held.
+func (t *Tracer) lane(id uint64) string {
+	name, ok := t.labels[id]
+	if !ok {
+		name = "g" + strconv.FormatUint(id, 10)
+		t.labels[id] = name
+	}
+	for _, l := range t.lanes {
+		if l == name {
+			return name
+		}
+	}
+	t.lanes = append(t.lanes, name)
+	return name
+}
+
+// Event records that name happened now in the lane of the calling goroutine.
+func (t *Tracer) Event(name string) {
+	id, now := goid(), t.clock.Now()
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	at := now.Sub(t.start)
+	t.records = append(t.records, Record{Lane: t.lane(id), Name: name, Start: at, End: at, Event: true})
+}
+
+// Span is a span in progress.
+type Span struct {
+	t     *Tracer
+	lane  string
+	name  string
+	start time.Time
+	once  sync.Once
+}
+
+// Begin starts a span in the lane of the calling goroutine, it is recorded when End is called:
+//
+//	defer t.Begin("fetch").End()
+func (t *Tracer) Begin(name string) *Span {
+	id, now := goid(), t.clock.Now()
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return &Span{t: t, lane: t.lane(id), name: name, start: now}
+}
+
+// End ends the span, from any goroutine. Later calls do nothing.
+func (s *Span) End() {
+	s.once.Do(func() {
+		now := s.t.clock.Now()
+		s.t.mux.Lock()
+		defer s.t.mux.Unlock()
+		s.t.records = append(s.t.records, Record{Lane: s.lane, Name: s.name, Start: s.start.Sub(s.t.start), End: now.Sub(s.t.start)})
+	})
+}
+
+// Records returns the records so far, in the order they were completed.
+func (t *Tracer) Records() []Record {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return append([]Record(nil), t.records...)
+}
+
+// Lanes returns the lanes in order of appearance.
+func (t *Tracer) Lanes() []string {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return append([]string(nil), t.lanes...)
+}
+
+// Reset forgets the records and restarts the time at 0, the labels are kept.
+func (t *Tracer) Reset() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.start = t.clock.Now()
+	t.records = nil
+	t.lanes = nil
+}
@@ -0,0 +1,89 @@
+package trace
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-demo/clock"
+)
+
+var t0 = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func TestNesting(t *testing.T) {
+	clk := clock.NewFake(t0)
+	tr := New(clk)
+	tr.Label("main")
+	outer := tr.Begin("fetch")
+	clk.Advance(2 * time.Second)
+	inner := tr.Begin("parse")
+	clk.Advance(4 * time.Second)
+	tr.Event("done")
+	inner.End()
+	clk.Advance(4 * time.Second)
+	outer.End()
+	outer.End() // does nothing
+
+	// In the order they were completed: the inner span before the outer one.
+	want := []Record{
+		{Lane: "main", Name: "done", Start: 6 * time.Second, End: 6 * time.Second, Event: true},
+		{Lane: "main", Name: "parse", Start: 2 * time.Second, End: 6 * time.Second},
+		{Lane: "main", Name: "fetch", Start: 0, End: 10 * time.Second},
+	}
+	if got := tr.Records(); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLanes(t *testing.T) {
+	tr := New(clock.NewFake(t0))
+	done := make(chan struct{})
+	for range 2 {
+		go func() {
+			tr.Event("hi")
+			done <- struct{}{}
+		}()
+		<-done
+	}
+	tr.Label("main")
+	tr.Event("hi")
+	got := tr.Lanes()
+	if len(got) != 3 || got[0] == got[1] || !strings.HasPrefix(got[0], "g") || !strings.HasPrefix(got[1], "g") || got[2] != "main" {
+		t.Errorf("got %q, want two goroutine lanes then main", got)
+	}
+
+	// Goroutines with the same label share a lane.
+	go func() {
+		tr.Label("main")
+		tr.Event("hi")
+		done <- struct{}{}
+	}()
+	<-done
+	if n := len(tr.Lanes()); n != 3 {
+		t.Errorf("got %d lanes, want 3", n)
+	}
+}
+
+func TestReset(t *testing.T) {
+	clk := clock.NewFake(t0)
+	tr := New(clk)
+	tr.Label("main")
+	sp := tr.Begin("long")
+	clk.Advance(time.Second)
+	tr.Reset()
+	if len(tr.Records()) != 0 || len(tr.Lanes()) != 0 {
+		t.Errorf("after Reset: got %v in %q", tr.Records(), tr.Lanes())
+	}
+	clk.Advance(time.Second)
+	tr.Event("after")
+	sp.End()
+	// The label is kept, the time restarts at the Reset: a span begun before starts before 0.
+	want := []Record{
+		{Lane: "main", Name: "after", Start: time.Second, End: time.Second, Event: true},
+		{Lane: "main", Name: "long", Start: -time.Second, End: time.Second},
+	}
+	if got := tr.Records(); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package tree
+
+import (
+	"context"
+	"iter"
+)
+
+// Order is the order in which a traversal visits the nodes of a tree.
+type Order int
+
+const (
+	// InOrder visits the left subtree, the node, then the right subtree: ascending values.
+	InOrder Order = iota
+	// ReverseOrder visits the right subtree, the node, then the left subtree: descending values.
+	ReverseOrder
+	// PreOrder visits the node before its subtrees (left first).
+	PreOrder
+	// PostOrder visits the node after its subtrees (left first).
+	PostOrder
+	// LevelOrder visits the nodes breadth-first: the root, then its children, then theirs, left to right.
+	LevelOrder
+)
+
+func (o Order) String() string {
+	switch o {
+	case InOrder:
+		return "in-order"
+	case ReverseOrder:
+		return "reverse"
+	case PreOrder:
+		return "pre-order"
+	case PostOrder:
+		return "post-order"
+	case LevelOrder:
+		return "level-order"
+	}
+	return "unknown order"
+}
+
+// Values returns an iterator over the values of the tree in the given order.
+func (t *Tree[T]) Values(o Order) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.Visit(o, yield)
+	}
+}
+
+// Visit calls fn on the values of the tree in the given order, until fn returns false.
+func (t *Tree[T]) Visit(o Order, fn func(T) bool) {
+	switch o {
+	case InOrder:
+		t.root.walk(fn)
+	case ReverseOrder:
+		t.root.walkReverse(fn)
+	case PreOrder:
+		t.root.walkPre(fn)
+	case PostOrder:
+		t.root.walkPost(fn)
+	case LevelOrder:
+		t.root.walkLevels(fn)
+	default:
+		panic("tree: " + o.String())
+	}
+}
+
+func (n *Node[T]) walkReverse(yield func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.Right.walkReverse(yield) && yield(n.Value) && n.Left.walkReverse(yield)
+}
+
+func (n *Node[T]) walkPre(yield func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return yield(n.Value) && n.Left.walkPre(yield) && n.Right.walkPre(yield)
+}
+
+func (n *Node[T]) walkPost(yield func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.Left.walkPost(yield) && n.Right.walkPost(yield) && yield(n.Value)
+}
+
+func (n *Node[T]) walkLevels(yield func(T) bool) {
+	if n == nil {
+		return
+	}
+	queue := []*Node[T]{n}
+	for len(queue) > 0 {
+		n, queue = queue[0], queue[1:]
+		if !yield(n.Value) {
+			return
+		}
+		if n.Left != nil {
+			queue = append(queue, n.Left)
+		}
+		if n.Right != nil {
+			queue = append(queue, n.Right)
+		}
+	}
+}
+
+// WalkOrder sends the values of the tree to ch in the given order until ctx is done, then closes ch.
+// It returns ctx.Err() if the walk was interrupted.
+func WalkOrder[T any](ctx context.Context, t *Tree[T], o Order, ch chan<- T) error {
+	defer close(ch)
+	for v := range t.Values(o) {
+		select {
+		case ch <- v:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// SameValues reports whether both trees hold the same values, whatever their shapes.
+func SameValues[T comparable](t1, t2 *Tree[T]) bool {
+	if t1.Len() != t2.Len() {
+		return false
+	}
+	next1, stop1 := t1.Pull()
+	defer stop1()
+	next2, stop2 := t2.Pull()
+	defer stop2()
+	for {
+		v1, ok1 := next1()
+		v2, ok2 := next2()
+		if ok1 != ok2 || v1 != v2 {
+			return false
+		}
+		if !ok1 {
+			return true
+		}
+	}
+}
+
+// SameShape reports whether both trees have the same structure, with the same values at the same places.
+// Two trees can hold the same values but differ in shape, e.g. when the values were inserted in different orders.
+func SameShape[T comparable](t1, t2 *Tree[T]) bool {
+	return sameShape(t1.root, t2.root)
+}
+
+func sameShape[T comparable](n1, n2 *Node[T]) bool {
+	if n1 == nil || n2 == nil {
+		return n1 == n2
+	}
+	return n1.Value == n2.Value && sameShape(n1.Left, n2.Left) && sameShape(n1.Right, n2.Right)
+}
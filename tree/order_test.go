@@ -0,0 +1,115 @@
+package tree
+
+import (
+	"slices"
+	"testing"
+)
+
+// Inserted in this order, the values make a perfect tree without rotation:
+//
+//	     4
+//	   /   \
+//	  2     6
+//	 / \   / \
+//	1   3 5   7
+var perfect = []int{4, 2, 6, 1, 3, 5, 7}
+
+func TestOrders(t *testing.T) {
+	tests := []struct {
+		order Order
+		want  []int
+	}{
+		{InOrder, []int{1, 2, 3, 4, 5, 6, 7}},
+		{ReverseOrder, []int{7, 6, 5, 4, 3, 2, 1}},
+		{PreOrder, []int{4, 2, 1, 3, 6, 5, 7}},
+		{PostOrder, []int{1, 3, 2, 5, 7, 6, 4}},
+		{LevelOrder, []int{4, 2, 6, 1, 3, 5, 7}},
+	}
+	tr := fromValues(perfect...)
+	for _, tt := range tests {
+		if got := slices.Collect(tr.Values(tt.order)); !slices.Equal(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.order, got, tt.want)
+		}
+		if got := slices.Collect(New[int]().Values(tt.order)); len(got) != 0 {
+			t.Errorf("%v of an empty tree: got %v", tt.order, got)
+		}
+		// Visit stops as soon as fn returns false, after the third value.
+		var got []int
+		tr.Visit(tt.order, func(v int) bool {
+			got = append(got, v)
+			return len(got) < 3
+		})
+		if !slices.Equal(got, tt.want[:3]) {
+			t.Errorf("%v stopped after 3: got %v, want %v", tt.order, got, tt.want[:3])
+		}
+		// So does breaking out of a range loop.
+		got = nil
+		for v := range tr.Values(tt.order) {
+			got = append(got, v)
+			if len(got) == 5 {
+				break
+			}
+		}
+		if !slices.Equal(got, tt.want[:5]) {
+			t.Errorf("%v with break: got %v, want %v", tt.order, got, tt.want[:5])
+		}
+	}
+}
+
+func TestOrderString(t *testing.T) {
+	tests := []struct {
+		order Order
+		want  string
+	}{
+		{InOrder, "in-order"},
+		{ReverseOrder, "reverse"},
+		{PreOrder, "pre-order"},
+		{PostOrder, "post-order"},
+		{LevelOrder, "level-order"},
+		{Order(42), "unknown order"},
+	}
+	for _, tt := range tests {
+		if got := tt.order.String(); got != tt.want {
+			t.Errorf("Order(%d): got %q, want %q", int(tt.order), got, tt.want)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Visit of an unknown order didn't panic")
+		}
+	}()
+	fromValues(1).Visit(Order(42), func(int) bool { return true })
+}
+
+func TestSameShape(t *testing.T) {
+	tests := []struct {
+		name       string
+		v1, v2     []int
+		same       bool
+		sameValues bool
+	}{
+		{"empty", nil, nil, true, true},
+		{"empty and not", nil, []int{1}, false, false},
+		{"same insertions", perfect, perfect, true, true},
+		// The order of the children's insertions doesn't matter, only where they land.
+		{"siblings swapped", perfect, []int{4, 6, 2, 7, 5, 3, 1}, true, true},
+		// Sorted insertions rotate into the same perfect tree.
+		{"rotated into shape", perfect, []int{1, 2, 3, 4, 5, 6, 7}, true, true},
+		// 1, 2 puts 2 below 1, 2, 1 the other way around.
+		{"same values, other shape", []int{1, 2}, []int{2, 1}, false, true},
+		{"same shape, other values", []int{2, 1, 3}, []int{5, 4, 6}, false, false},
+		{"one value more", perfect, append(slices.Clone(perfect), 8), false, false},
+	}
+	for _, tt := range tests {
+		t1, t2 := fromValues(tt.v1...), fromValues(tt.v2...)
+		if got := SameShape(t1, t2); got != tt.same {
+			t.Errorf("%s: SameShape got %v, want %v", tt.name, got, tt.same)
+		}
+		if got := SameShape(t2, t1); got != tt.same {
+			t.Errorf("%s: SameShape swapped got %v, want %v", tt.name, got, tt.same)
+		}
+		if got := SameValues(t1, t2); got != tt.sameValues {
+			t.Errorf("%s: SameValues got %v, want %v", tt.name, got, tt.sameValues)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package tree implements an ordered set as a self-balancing (AVL) binary search tree.
+// It replaces golang.org/x/tour/tree for the tree exercises, and also builds trees of any ordered type.
+package tree
+
+import (
+	"cmp"
+	"math/rand"
+)
+
+// Node is a node of a Tree. Its fields are read-only, changing them would break the tree's invariants.
+type Node[T any] struct {
+	Left   *Node[T]
+	Value  T
+	Right  *Node[T]
+	height int
+}
+
+// Tree is an ordered set of values, kept balanced so that every operation takes O(log n).
+// The zero value is not usable, create trees with New or NewFunc.
+type Tree[T any] struct {
+	root *Node[T]
+	len  int
+	cmp  func(a, b T) int
+}
+
+// New returns an empty tree of naturally ordered values.
+func New[T cmp.Ordered]() *Tree[T] {
+	return NewFunc[T](cmp.Compare[T])
+}
+
+// NewFunc returns an empty tree ordered by cmp, which returns a negative number when a < b,
+// a positive number when a > b and zero when a and b are equal.
+func NewFunc[T any](cmp func(a, b T) int) *Tree[T] {
+	return &Tree[T]{cmp: cmp}
+}
+
+// NewRandom returns a tree holding the values k, 2k, ..., 10k inserted in random order,
+// the same values as golang.org/x/tour/tree.New(k).
+func NewRandom(k int) *Tree[int] {
+	t := New[int]()
+	for _, v := range rand.Perm(10) {
+		t.Insert((1 + v) * k)
+	}
+	return t
+}
+
+// Root returns the root node of the tree, nil if it is empty.
+func (t *Tree[T]) Root() *Node[T] {
+	return t.root
+}
+
+// Len returns the number of values in the tree.
+func (t *Tree[T]) Len() int {
+	return t.len
+}
+
+// Find returns the value of the tree equal to v, if any.
+func (t *Tree[T]) Find(v T) (T, bool) {
+	n := t.root
+	for n != nil {
+		switch c := t.cmp(v, n.Value); {
+		case c < 0:
+			n = n.Left
+		case c > 0:
+			n = n.Right
+		default:
+			return n.Value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Contains reports whether v is in the tree.
+func (t *Tree[T]) Contains(v T) bool {
+	_, ok := t.Find(v)
+	return ok
+}
+
+// Min returns the smallest value of the tree, ok is false if the tree is empty.
+func (t *Tree[T]) Min() (v T, ok bool) {
+	if t.root == nil {
+		return v, false
+	}
+	return t.root.min().Value, true
+}
+
+// Max returns the largest value of the tree, ok is false if the tree is empty.
+func (t *Tree[T]) Max() (v T, ok bool) {
+	n := t.root
+	if n == nil {
+		return v, false
+	}
+	for n.Right != nil {
+		n = n.Right
+	}
+	return n.Value, true
+}
+
+// Insert adds v to the tree. If an equal value is already there, it is replaced and Insert returns false.
+func (t *Tree[T]) Insert(v T) bool {
+	var added bool
+	t.root = t.insert(t.root, v, &added)
+	if added {
+		t.len++
+	}
+	return added
+}
+
+func (t *Tree[T]) insert(n *Node[T], v T, added *bool) *Node[T] {
+	if n == nil {
+		*added = true
+		return &Node[T]{Value: v, height: 1}
+	}
+	switch c := t.cmp(v, n.Value); {
+	case c < 0:
+		n.Left = t.insert(n.Left, v, added)
+	case c > 0:
+		n.Right = t.insert(n.Right, v, added)
+	default:
+		n.Value = v
+		return n
+	}
+	return n.rebalance()
+}
+
+// Delete removes v from the tree and reports whether it was there.
+func (t *Tree[T]) Delete(v T) bool {
+	var deleted bool
+	t.root = t.delete(t.root, v, &deleted)
+	if deleted {
+		t.len--
+	}
+	return deleted
+}
+
+func (t *Tree[T]) delete(n *Node[T], v T, deleted *bool) *Node[T] {
+	if n == nil {
+		return nil
+	}
+	switch c := t.cmp(v, n.Value); {
+	case c < 0:
+		n.Left = t.delete(n.Left, v, deleted)
+	case c > 0:
+		n.Right = t.delete(n.Right, v, deleted)
+	default:
+		*deleted = true
+		if n.Left == nil {
+			return n.Right
+		}
+		if n.Right == nil {
+			return n.Left
+		}
+		// Two children: replace the value by its successor, then remove the successor from the right subtree.
+		succ := n.Right.min()
+		n.Value = succ.Value
+		n.Right = n.Right.deleteMin()
+	}
+	return n.rebalance()
+}
+
+func (n *Node[T]) min() *Node[T] {
+	for n.Left != nil {
+		n = n.Left
+	}
+	return n
+}
+
+func (n *Node[T]) deleteMin() *Node[T] {
+	if n.Left == nil {
+		return n.Right
+	}
+	n.Left = n.Left.deleteMin()
+	return n.rebalance()
+}
+
+// Height returns the number of levels below and including n, 0 for a nil node.
+func (n *Node[T]) Height() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *Node[T]) update() {
+	n.height = 1 + max(n.Left.Height(), n.Right.Height())
+}
+
+// rebalance restores the AVL invariant (subtree heights differ by at most one) at n
+// after an insertion or deletion below it, and returns the new root of the subtree.
+func (n *Node[T]) rebalance() *Node[T] {
+	n.update()
+	switch balance := n.Left.Height() - n.Right.Height(); {
+	case balance > 1:
+		if n.Left.Left.Height() < n.Left.Right.Height() {
+			n.Left = n.Left.rotateLeft()
+		}
+		return n.rotateRight()
+	case balance < -1:
+		if n.Right.Right.Height() < n.Right.Left.Height() {
+			n.Right = n.Right.rotateRight()
+		}
+		return n.rotateLeft()
+	}
+	return n
+}
+
+func (n *Node[T]) rotateLeft() *Node[T] {
+	r := n.Right
+	n.Right = r.Left
+	r.Left = n
+	n.update()
+	r.update()
+	return r
+}
+
+func (n *Node[T]) rotateRight() *Node[T] {
+	l := n.Left
+	n.Left = l.Right
+	l.Right = n
+	n.update()
+	l.update()
+	return l
+}
@@ -0,0 +1,117 @@
+package tree
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// checkAVL checks the invariants of the subtree of n and returns its height: the heights kept in the nodes
+// are right, and the heights of the two subtrees of every node differ by at most one.
+func checkAVL(t *testing.T, n *Node[int]) int {
+	t.Helper()
+	if n == nil {
+		return 0
+	}
+	l, r := checkAVL(t, n.Left), checkAVL(t, n.Right)
+	h := 1 + max(l, r)
+	if n.height != h {
+		t.Fatalf("node %d: height %d, want %d", n.Value, n.height, h)
+	}
+	if l-r > 1 || r-l > 1 {
+		t.Fatalf("node %d: unbalanced, subtrees of heights %d and %d", n.Value, l, r)
+	}
+	return h
+}
+
+// checkTree checks that tr holds exactly the values of want, in order, and is a valid AVL tree.
+func checkTree(t *testing.T, tr *Tree[int], want map[int]bool) {
+	t.Helper()
+	h := checkAVL(t, tr.Root())
+	got := slices.Collect(tr.All())
+	if !sort.IntsAreSorted(got) || len(got) != len(want) || tr.Len() != len(want) {
+		t.Fatalf("got %v (Len %d), want the %d values of %v in order", got, tr.Len(), len(want), want)
+	}
+	for i, v := range got {
+		if !want[v] || (i > 0 && got[i-1] == v) {
+			t.Fatalf("got %v, want the values of %v", got, want)
+		}
+	}
+	// An AVL tree of n nodes is less than 1.5 log2(2n) high.
+	if n := len(want); n > 0 && 1<<((h*2)/3) > 2*n {
+		t.Fatalf("height %d for %d values", h, n)
+	}
+}
+
+func TestInsertDelete(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 10; round++ {
+		tr := New[int]()
+		want := map[int]bool{}
+		for i := 0; i < 500; i++ {
+			v := rng.Intn(200)
+			// More inserts than deletes at first, then the other way around, so the tree grows and shrinks.
+			if rng.Intn(500) > i {
+				if added := tr.Insert(v); added == want[v] {
+					t.Fatalf("Insert(%d): got %v with %v in the tree", v, added, want[v])
+				}
+				want[v] = true
+			} else {
+				if deleted := tr.Delete(v); deleted != want[v] {
+					t.Fatalf("Delete(%d): got %v with %v in the tree", v, deleted, want[v])
+				}
+				delete(want, v)
+			}
+			checkTree(t, tr, want)
+			if tr.Contains(v) != want[v] {
+				t.Fatalf("Contains(%d): got %v", v, !want[v])
+			}
+		}
+	}
+}
+
+func TestAscending(t *testing.T) {
+	// Sorted insertions, the worst case of an unbalanced tree, give a perfect tree of 2^k - 1 values.
+	tr := New[int]()
+	want := map[int]bool{}
+	for v := 0; v < 127; v++ {
+		tr.Insert(v)
+		want[v] = true
+	}
+	checkTree(t, tr, want)
+	if h := tr.Root().Height(); h != 7 {
+		t.Errorf("height %d, want 7", h)
+	}
+	// Then deleting from one end.
+	for v := 0; v < 100; v++ {
+		tr.Delete(v)
+		delete(want, v)
+		checkTree(t, tr, want)
+	}
+	if lo, _ := tr.Min(); lo != 100 {
+		t.Errorf("Min: got %d, want 100", lo)
+	}
+	if hi, _ := tr.Max(); hi != 126 {
+		t.Errorf("Max: got %d, want 126", hi)
+	}
+}
+
+func TestInsertReplaces(t *testing.T) {
+	type kv struct {
+		k string
+		v int
+	}
+	tr := NewFunc(func(a, b kv) int { return cmp.Compare(a.k, b.k) })
+	tr.Insert(kv{"a", 1})
+	if tr.Insert(kv{"a", 2}) {
+		t.Error("Insert of an equal value: got true")
+	}
+	if got, ok := tr.Find(kv{k: "a"}); !ok || got.v != 2 || tr.Len() != 1 {
+		t.Errorf("Find: got %v, %v with Len %d, want the replaced value", got, ok, tr.Len())
+	}
+	if _, ok := New[int]().Min(); ok {
+		t.Error("Min of an empty tree: got ok")
+	}
+}
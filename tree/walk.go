@@ -0,0 +1,67 @@
+package tree
+
+import (
+	"context"
+	"iter"
+)
+
+// Walk sends the values of the tree to ch in ascending order, then closes ch.
+// It blocks until every value has been received, use WalkContext if the receiver may stop early.
+func Walk[T any](t *Tree[T], ch chan<- T) {
+	WalkContext(context.Background(), t, ch)
+}
+
+// WalkContext sends the values of the tree to ch in ascending order until ctx is done, then closes ch.
+// It returns ctx.Err() if the walk was interrupted.
+// Cancelling ctx is how a receiver that stops early lets the walking goroutine exit.
+// See WalkOrder for the other traversal orders.
+func WalkContext[T any](ctx context.Context, t *Tree[T], ch chan<- T) error {
+	return WalkOrder(ctx, t, InOrder, ch)
+}
+
+// All returns an iterator over the values of the tree in ascending order.
+func (t *Tree[T]) All() iter.Seq[T] {
+	return t.Values(InOrder)
+}
+
+// Pull returns the values of the tree one at a time: next returns the following value,
+// or false once they are exhausted. Call stop when done, even before the end:
+// iter.Pull runs the walk in a coroutine, a goroutine of its own that only exits once the walk
+// is over or stop is called. Unlike with Walk, the caller decides when each value is produced.
+func (t *Tree[T]) Pull() (next func() (T, bool), stop func()) {
+	return iter.Pull(t.All())
+}
+
+// walk calls yield on the values of the subtree in order, it returns false once yield has.
+func (n *Node[T]) walk(yield func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.Left.walk(yield) && yield(n.Value) && n.Right.walk(yield)
+}
+
+// Range returns an iterator over the values v of the tree such that lo <= v < hi, in ascending order.
+// Only the subtrees that may hold such values are visited.
+func (t *Tree[T]) Range(lo, hi T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.walkRange(t.root, lo, hi, yield)
+	}
+}
+
+func (t *Tree[T]) walkRange(n *Node[T], lo, hi T, yield func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	aboveLo := t.cmp(n.Value, lo) >= 0
+	belowHi := t.cmp(n.Value, hi) < 0
+	if aboveLo && !t.walkRange(n.Left, lo, hi, yield) {
+		return false
+	}
+	if aboveLo && belowHi && !yield(n.Value) {
+		return false
+	}
+	if belowHi {
+		return t.walkRange(n.Right, lo, hi, yield)
+	}
+	return true
+}
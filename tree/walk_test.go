@@ -0,0 +1,190 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"golang-demo/leaktest"
+)
+
+func fromValues(vs ...int) *Tree[int] {
+	t := New[int]()
+	for _, v := range vs {
+		t.Insert(v)
+	}
+	return t
+}
+
+// sameByWalk compares the trees with two walking goroutines, like SameTree in main/14-goroutines.go:
+// returning at the first difference cancels the walkers, which would block on their next send otherwise.
+func sameByWalk(t1, t2 *Tree[int]) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c1, c2 := make(chan int), make(chan int)
+	go WalkContext(ctx, t1, c1)
+	go WalkContext(ctx, t2, c2)
+	for {
+		v1, ok1 := <-c1
+		v2, ok2 := <-c2
+		if ok1 != ok2 || v1 != v2 {
+			return false
+		}
+		if !ok1 {
+			return true
+		}
+	}
+}
+
+// samePull compares the trees with pull iterators, like SameTreePull in main/14-goroutines.go.
+// Unlike SameValues it doesn't compare the lengths first, so trees of different lengths go through the loop.
+func samePull(t1, t2 *Tree[int]) bool {
+	next1, stop1 := t1.Pull()
+	defer stop1()
+	next2, stop2 := t2.Pull()
+	defer stop2()
+	for {
+		v1, ok1 := next1()
+		v2, ok2 := next2()
+		if ok1 != ok2 || v1 != v2 {
+			return false
+		}
+		if !ok1 {
+			return true
+		}
+	}
+}
+
+var sameTests = []struct {
+	name   string
+	t1, t2 *Tree[int]
+	want   bool
+}{
+	{"equal", fromValues(1, 2, 3, 4, 5), fromValues(5, 3, 1, 4, 2), true},
+	{"random", NewRandom(1), NewRandom(1), true},
+	{"empty", fromValues(), fromValues(), true},
+	{"first differs", fromValues(1, 2, 3, 4, 5), fromValues(0, 2, 3, 4, 5), false},
+	{"last differs", NewRandom(1), fromValues(1, 2, 3, 4, 5, 6, 7, 8, 9, 11), false},
+	{"shorter", fromValues(1, 2, 3), fromValues(1, 2, 3, 4, 5), false},
+	{"longer", NewRandom(2), fromValues(2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24), false},
+	{"one empty", fromValues(), fromValues(1), false},
+}
+
+func TestSame(t *testing.T) {
+	funcs := []struct {
+		name string
+		same func(t1, t2 *Tree[int]) bool
+	}{
+		{"SameValues", SameValues[int]},
+		{"walk", sameByWalk},
+		{"pull", samePull},
+	}
+	for _, f := range funcs {
+		for _, tt := range sameTests {
+			t.Run(f.name+"/"+tt.name, func(t *testing.T) {
+				defer leaktest.Check(t, leaktest.Options{})()
+				if got := f.same(tt.t1, tt.t2); got != tt.want {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+				if got := f.same(tt.t2, tt.t1); got != tt.want {
+					t.Errorf("swapped: got %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestWalkContext(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	tr := NewRandom(3)
+	ch := make(chan int)
+	go Walk(tr, ch)
+	var got []int
+	for v := range ch {
+		got = append(got, v)
+	}
+	if want := []int{3, 6, 9, 12, 15, 18, 21, 24, 27, 30}; !slices.Equal(got, want) {
+		t.Fatalf("Walk: got %v, want %v", got, want)
+	}
+
+	// A receiver that stops after one value cancels the walk, which returns the cause.
+	ctx, cancel := context.WithCancel(context.Background())
+	ch = make(chan int)
+	errc := make(chan error, 1)
+	go func() { errc <- WalkContext(ctx, tr, ch) }()
+	if v := <-ch; v != 3 {
+		t.Fatalf("first value: got %d, want 3", v)
+	}
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WalkContext didn't return after cancel")
+	}
+	// WalkContext closes the channel before returning, even when interrupted.
+	for range ch {
+	}
+}
+
+func TestPull(t *testing.T) {
+	defer leaktest.Check(t, leaktest.Options{})()
+	next, stop := fromValues(2, 1, 3).Pull()
+	for _, want := range []int{1, 2, 3} {
+		if v, ok := next(); !ok || v != want {
+			t.Fatalf("got %d, %v, want %d, true", v, ok, want)
+		}
+	}
+	if _, ok := next(); ok {
+		t.Fatal("next after the last value: got true")
+	}
+	stop()
+	stop() // stop may be called more than once
+}
+
+// TestPullStop checks that the coroutine behind Pull runs until stop is called.
+func TestPullStop(t *testing.T) {
+	before := leaktest.Snapshot()
+	next, stop := NewRandom(1).Pull()
+	next()
+	if leaked := leaktest.Leaked(before, leaktest.Options{Timeout: 50 * time.Millisecond}); len(leaked) == 0 {
+		t.Error("no goroutine running for an unfinished Pull")
+	}
+	stop()
+	if leaked := leaktest.Leaked(before, leaktest.Options{}); len(leaked) > 0 {
+		t.Error(leaktest.Report(leaked))
+	}
+	if _, ok := next(); ok {
+		t.Error("next after stop: got true")
+	}
+}
+
+func TestRange(t *testing.T) {
+	tr := NewRandom(1) // 1..10
+	tests := []struct {
+		lo, hi int
+		want   []int
+	}{
+		{3, 6, []int{3, 4, 5}},
+		{0, 100, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{5, 5, nil},
+		{6, 3, nil},
+		{10, 11, []int{10}},
+	}
+	for _, tt := range tests {
+		if got := slices.Collect(tr.Range(tt.lo, tt.hi)); !slices.Equal(got, tt.want) {
+			t.Errorf("Range(%d, %d): got %v, want %v", tt.lo, tt.hi, got, tt.want)
+		}
+	}
+	// Stopping early is fine too.
+	for v := range tr.Range(2, 8) {
+		if v != 2 {
+			t.Errorf("first value: got %d, want 2", v)
+		}
+		break
+	}
+}